@@ -1,20 +1,82 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/jackharrisonsherlock/govend/vend"
+	"github.com/jackharrisonsherlock/govend/vend/export"
 )
 
 var (
 	token        string
 	domainPrefix string
 	tz           string
+	dateFrom     string
+	dateTo       string
+	output       string
 )
 
 func main() {
-	vend.NewClient(token, domainPrefix, tz)
+	client := vend.NewClient(token, domainPrefix, tz)
+
+	// Nothing to export without a start date.
+	if dateFrom == "" {
+		return
+	}
+
+	if err := exportLedger(&client); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// exportLedger writes the sales ledger for the business days from dateFrom
+// to dateTo inclusive.
+func exportLedger(client *vend.Client) error {
+	clock, err := client.Clock()
+	if err != nil {
+		return err
+	}
+
+	from, err := time.Parse("2006-01-02", dateFrom)
+	if err != nil {
+		return fmt.Errorf("parsing -from date: %w", err)
+	}
+	to := from
+	if dateTo != "" {
+		to, err = time.Parse("2006-01-02", dateTo)
+		if err != nil {
+			return fmt.Errorf("parsing -to date: %w", err)
+		}
+	}
+	if to.Before(from) {
+		return fmt.Errorf("-to date %s is before -from date %s", dateTo, dateFrom)
+	}
+
+	period := vend.Period{
+		Start: clock.Date(from.Year(), from.Month(), from.Day()).Start,
+		End:   clock.Date(to.Year(), to.Month(), to.Day()).End,
+	}
+
+	ctx := context.Background()
+	if output == "" {
+		return export.Export(ctx, client, period, os.Stdout)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := export.Export(ctx, client, period, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
 
 func init() {
@@ -26,6 +88,12 @@ func init() {
 		"Personal API Access Token for the store, generated from Setup -> API Access.")
 	flag.StringVar(&tz, "z", "Local",
 		"Timezone of the store in zoneinfo format. The default is to try and use the computer's local timezone.")
+	flag.StringVar(&dateFrom, "from", "",
+		"Export the sales ledger from this date (YYYY-MM-DD) in the store's timezone.")
+	flag.StringVar(&dateTo, "to", "",
+		"Export the sales ledger up to and including this date (YYYY-MM-DD). Defaults to the -from date.")
+	flag.StringVar(&output, "o", "",
+		"File to write the sales ledger CSV to. Defaults to standard output.")
 	flag.Parse()
 
 	// To save people who write DomainPrefix.vendhq.com.
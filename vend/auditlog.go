@@ -1,6 +1,7 @@
 package vend
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -18,23 +19,31 @@ type AuditLog struct {
 	EntityID   *string `json:"entity_id"`
 	IPAddress  *string `json:"ip_address"`
 	UserAgent  *string `json:"user_agent"`
-	OccurredAt *string `json:"occurred_at"`
-	CreatedAt  *string `json:"created_at"`
+	OccurredAt *Time   `json:"occurred_at"`
+	CreatedAt  *Time   `json:"created_at"`
 }
 
 // Auditlog grabs and collates all logs in pages of 1,000.
 func (c *Client) AuditLog(dateFrom, dateTo string) ([]AuditLog, error) {
+	return c.AuditLogContext(context.Background(), dateFrom, dateTo)
+}
+
+// AuditLogContext is like AuditLog but stops when ctx is done.
+func (c *Client) AuditLogContext(ctx context.Context, dateFrom, dateTo string) ([]AuditLog, error) {
 
 	currentOffset := 0
 	audit := []AuditLog{}
 
 	// Build the URL for the endpoint.
-	url := fmt.Sprintf("https://%s.vendhq.com/api/2.0/auditlog_events?from=%s&to=%s&offset=%v", c.DomainPrefix, dateFrom, dateTo, currentOffset)
-	data, _, err := c.MakeRequest("GET", url, nil)
+	url := fmt.Sprintf("%s/api/2.0/auditlog_events?from=%s&to=%s&offset=%v", c.baseURL(), dateFrom, dateTo, currentOffset)
+	data, _, err := c.MakeRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
 	response := &AuditResponse{}
 	err = json.Unmarshal(data, response)
 	if err != nil {
-		fmt.Printf("\nError unmarshalling Vend register payload: %s", err)
+		c.logger().ErrorContext(ctx, "unmarshalling audit log payload", "offset", currentOffset, "error", err)
 		return nil, err
 	}
 
@@ -48,12 +57,15 @@ func (c *Client) AuditLog(dateFrom, dateTo string) ([]AuditLog, error) {
 		currentOffset += lastCount
 
 		// Build the URL for the endpoint including the offset
-		url := fmt.Sprintf("https://%s.vendhq.com/api/2.0/auditlog_events?from=%s&to=%s&offset=%v", c.DomainPrefix, dateFrom, dateTo, currentOffset)
-		data, _, err := c.MakeRequest("GET", url, nil)
+		url := fmt.Sprintf("%s/api/2.0/auditlog_events?from=%s&to=%s&offset=%v", c.baseURL(), dateFrom, dateTo, currentOffset)
+		data, _, err := c.MakeRequestContext(ctx, "GET", url, nil)
+		if err != nil {
+			return audit, err
+		}
 		response := &AuditResponse{}
 		err = json.Unmarshal(data, response)
 		if err != nil {
-			fmt.Printf("\nError unmarshalling Vend register payload: %s", err)
+			c.logger().ErrorContext(ctx, "unmarshalling audit log payload", "offset", currentOffset, "error", err)
 			return audit, err
 		}
 
@@ -0,0 +1,120 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"time"
+)
+
+// Clock tells the time in a store's timezone and works out the business
+// days its sales are reported by. Business days start at midnight unless
+// the client was created WithBusinessDayStart.
+//
+//	clock, err := client.Clock()
+//	day := clock.Today()
+//	sales, err := client.SalesBetween(ctx, day.Start, day.End)
+type Clock struct {
+	loc *time.Location
+	// dayStart is how long after midnight each business day begins.
+	dayStart time.Duration
+	now      func() time.Time
+}
+
+// Period is the span of time from Start up to but not including End.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t falls within the period.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Clock returns the store's clock, in the client's TimeZone.
+func (c *Client) Clock() (Clock, error) {
+	loc, err := loadLocation(c.TimeZone)
+	if err != nil {
+		return Clock{}, err
+	}
+	return Clock{loc: loc, dayStart: c.config().dayStart, now: time.Now}, nil
+}
+
+// Location is the store's timezone.
+func (k Clock) Location() *time.Location {
+	if k.loc == nil {
+		return time.UTC
+	}
+	return k.loc
+}
+
+// Now is the current time in the store.
+func (k Clock) Now() time.Time {
+	if k.now == nil {
+		return k.In(time.Now())
+	}
+	return k.In(k.now())
+}
+
+// In converts t to the store's timezone.
+func (k Clock) In(t time.Time) time.Time {
+	return t.In(k.Location())
+}
+
+// StartOfDay is when the business day containing t began.
+func (k Clock) StartOfDay(t time.Time) time.Time {
+	return k.Day(t).Start
+}
+
+// EndOfDay is when the business day containing t ends, which is also when
+// the next one starts.
+func (k Clock) EndOfDay(t time.Time) time.Time {
+	return k.Day(t).End
+}
+
+// Day is the business day containing t.
+func (k Clock) Day(t time.Time) Period {
+	return k.Date(k.businessDate(t))
+}
+
+// Date is the business day that starts on the given calendar date.
+func (k Clock) Date(year int, month time.Month, day int) Period {
+	return Period{
+		Start: k.dayStartOn(year, month, day),
+		End:   k.dayStartOn(year, month, day+1),
+	}
+}
+
+// Today is the current business day.
+func (k Clock) Today() Period {
+	return k.Day(k.Now())
+}
+
+// Days lists the business days overlapping the period from up to to, in
+// order, for reporting day by day.
+func (k Clock) Days(from, to time.Time) []Period {
+	days := []Period{}
+	y, m, d := k.businessDate(from)
+	for day := k.Date(y, m, d); day.Start.Before(to); day = k.Date(y, m, d) {
+		days = append(days, day)
+		d++
+	}
+	return days
+}
+
+// businessDate is the calendar date the business day containing t started on.
+func (k Clock) businessDate(t time.Time) (int, time.Month, int) {
+	t = k.In(t)
+	y, m, d := t.Date()
+	if t.Before(k.dayStartOn(y, m, d)) {
+		// Still trading on the previous day.
+		d--
+	}
+	return y, m, d
+}
+
+// dayStartOn is when the business day starting on a calendar date begins.
+// The offset is applied to the wall clock, so days are still correct when
+// daylight saving starts or ends.
+func (k Clock) dayStartOn(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, int(k.dayStart), k.Location())
+}
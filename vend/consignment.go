@@ -2,9 +2,10 @@
 package vend
 
 import (
-	"encoding/json"
-	"log"
-	"time"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
 )
 
 // Vend API Docs: https://docs.vendhq.com/v0.9/reference#consignments-2
@@ -17,37 +18,226 @@ type ConsignmentPayload struct {
 
 // Consignment is a ConsignmentPayload object.
 type Consignment struct {
-	ID              *string    `json:"id,omitempty"`
-	OutletID        *string    `json:"outlet_id,omitempty"`
-	Name            *string    `json:"name,omitempty"`
-	Type            *string    `json:"type,omitempty"`
-	Status          *string    `json:"status,omitempty"`
-	ConsignmentDate *string    `json:"consignment_date,omitempty"` // NOTE: Using string for ParseVendDT.
-	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+	ID              *string `json:"id,omitempty"`
+	OutletID        *string `json:"outlet_id,omitempty"`
+	Name            *string `json:"name,omitempty"`
+	Type            *string `json:"type,omitempty"`
+	Status          *string `json:"status,omitempty"`
+	SupplierID      *string `json:"supplier_id,omitempty"`
+	SourceOutletID  *string `json:"source_outlet_id,omitempty"`
+	Reference       *string `json:"reference,omitempty"`
+	SupplierInvoice *string `json:"supplier_invoice,omitempty"`
+	ConsignmentDate *Time   `json:"consignment_date,omitempty"`
+	DueAt           *Time   `json:"due_at,omitempty"`
+	ReceivedAt      *Time   `json:"received_at,omitempty"`
+	DeletedAt       *Time   `json:"deleted_at,omitempty"`
 }
 
 // Consignments gets all stock consignments and transfers from a store.
 func (c *Client) Consignments() ([]Consignment, error) {
+	return c.ConsignmentsContext(context.Background())
+}
 
-	var consignments, page []Consignment
-	var v int64
+// ConsignmentsContext is like Consignments but stops when ctx is done.
+func (c *Client) ConsignmentsContext(ctx context.Context) ([]Consignment, error) {
+	return collect[Consignment](ctx, c, "consignments", 0)
+}
 
-	// v is a version that is used to objects by page.
-	data, v, err := c.ResourcePage(0, "GET", "consignments")
-	err = json.Unmarshal(data, &page)
-	if err != nil {
-		log.Printf("error while unmarshalling: %s", err)
+// GetConsignment gets a single consignment by ID.
+func (c *Client) GetConsignment(ctx context.Context, id string) (*Consignment, error) {
+	return requestData[Consignment](ctx, c, "GET", "consignments/"+url.PathEscape(id), nil)
+}
+
+// Consignment types.
+const (
+	ConsignmentSupplier  = "SUPPLIER"
+	ConsignmentOutlet    = "OUTLET"
+	ConsignmentReturn    = "RETURN"
+	ConsignmentStocktake = "STOCKTAKE"
+)
+
+// Consignment statuses.
+const (
+	ConsignmentOpen                = "OPEN"
+	ConsignmentSent                = "SENT"
+	ConsignmentDispatched          = "DISPATCHED"
+	ConsignmentReceived            = "RECEIVED"
+	ConsignmentCancelled           = "CANCELLED"
+	ConsignmentStocktakeScheduled  = "STOCKTAKE_SCHEDULED"
+	ConsignmentStocktakeInProgress = "STOCKTAKE_IN_PROGRESS"
+	ConsignmentStocktakeComplete   = "STOCKTAKE_COMPLETE"
+)
+
+// ErrInvalidTransition is returned when a consignment cannot move from its
+// current status to the one asked for.
+var ErrInvalidTransition = errors.New("vend: invalid consignment status transition")
+
+// consignmentTransitions lists the statuses each status can move to.
+var consignmentTransitions = map[string][]string{
+	ConsignmentOpen:                {ConsignmentSent, ConsignmentDispatched, ConsignmentReceived, ConsignmentCancelled},
+	ConsignmentSent:                {ConsignmentDispatched, ConsignmentReceived, ConsignmentCancelled},
+	ConsignmentDispatched:          {ConsignmentReceived, ConsignmentCancelled},
+	ConsignmentStocktakeScheduled:  {ConsignmentStocktakeInProgress, ConsignmentCancelled},
+	ConsignmentStocktakeInProgress: {ConsignmentStocktakeComplete, ConsignmentCancelled},
+}
+
+// ValidConsignmentTransition reports whether a consignment can move from one
+// status to another. Received, cancelled and completed consignments are final.
+func ValidConsignmentTransition(from, to string) bool {
+	for _, status := range consignmentTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ConsignmentRequest holds the fields sent when creating or updating a
+// consignment. Only fields that are set are sent.
+type ConsignmentRequest struct {
+	Name            *string `json:"name,omitempty"`
+	Type            *string `json:"type,omitempty"`
+	Status          *string `json:"status,omitempty"`
+	OutletID        *string `json:"outlet_id,omitempty"`
+	SourceOutletID  *string `json:"source_outlet_id,omitempty"`
+	SupplierID      *string `json:"supplier_id,omitempty"`
+	Reference       *string `json:"reference,omitempty"`
+	SupplierInvoice *string `json:"supplier_invoice,omitempty"`
+	DueAt           *Time   `json:"due_at,omitempty"`
+}
+
+// CreateConsignment creates a consignment of any type. Supplier orders,
+// transfers, returns and stocktakes can be created more simply with
+// CreateSupplierOrder, CreateOutletTransfer, CreateReturn and CreateStocktake.
+func (c *Client) CreateConsignment(ctx context.Context, r ConsignmentRequest) (*Consignment, error) {
+	if isBlank(r.OutletID) {
+		return nil, errors.New("vend: consignment outlet ID is required")
+	}
+	if isBlank(r.Type) {
+		return nil, errors.New("vend: consignment type is required")
+	}
+
+	switch *r.Type {
+	case ConsignmentSupplier, ConsignmentReturn:
+	case ConsignmentOutlet:
+		if isBlank(r.SourceOutletID) {
+			return nil, errors.New("vend: an outlet transfer needs a source outlet ID")
+		}
+		if *r.SourceOutletID == *r.OutletID {
+			return nil, errors.New("vend: an outlet transfer needs two different outlets")
+		}
+	case ConsignmentStocktake:
+	default:
+		return nil, fmt.Errorf("vend: unknown consignment type %q", *r.Type)
+	}
+
+	if r.Status == nil {
+		status := ConsignmentOpen
+		if *r.Type == ConsignmentStocktake {
+			status = ConsignmentStocktakeScheduled
+		}
+		r.Status = &status
+	}
+
+	return requestData[Consignment](ctx, c, "POST", "consignments", r)
+}
+
+// CreateSupplierOrder creates an order of stock from a supplier into an outlet.
+func (c *Client) CreateSupplierOrder(ctx context.Context, outletID, supplierID, name string) (*Consignment, error) {
+	return c.CreateConsignment(ctx, ConsignmentRequest{
+		Name:       optional(name),
+		Type:       String(ConsignmentSupplier),
+		OutletID:   &outletID,
+		SupplierID: optional(supplierID),
+	})
+}
+
+// CreateOutletTransfer creates a transfer of stock from one outlet to another.
+func (c *Client) CreateOutletTransfer(ctx context.Context, fromOutletID, toOutletID, name string) (*Consignment, error) {
+	return c.CreateConsignment(ctx, ConsignmentRequest{
+		Name:           optional(name),
+		Type:           String(ConsignmentOutlet),
+		OutletID:       &toOutletID,
+		SourceOutletID: &fromOutletID,
+	})
+}
+
+// CreateReturn creates a return of stock from an outlet to a supplier.
+func (c *Client) CreateReturn(ctx context.Context, outletID, supplierID, name string) (*Consignment, error) {
+	return c.CreateConsignment(ctx, ConsignmentRequest{
+		Name:       optional(name),
+		Type:       String(ConsignmentReturn),
+		OutletID:   &outletID,
+		SupplierID: optional(supplierID),
+	})
+}
+
+// CreateStocktake schedules a stocktake at an outlet.
+func (c *Client) CreateStocktake(ctx context.Context, outletID, name string) (*Consignment, error) {
+	return c.CreateConsignment(ctx, ConsignmentRequest{
+		Name:     optional(name),
+		Type:     String(ConsignmentStocktake),
+		OutletID: &outletID,
+	})
+}
+
+// UpdateConsignment changes the fields set in r on a consignment. Use
+// SetConsignmentStatus to change its status.
+func (c *Client) UpdateConsignment(ctx context.Context, id string, r ConsignmentRequest) (*Consignment, error) {
+	if id == "" {
+		return nil, errors.New("vend: consignment ID is required")
+	}
+	if r.Status != nil {
+		return nil, errors.New("vend: use SetConsignmentStatus to change a consignment's status")
 	}
+	return requestData[Consignment](ctx, c, "PUT", "consignments/"+url.PathEscape(id), r)
+}
 
-	consignments = append(consignments, page...)
+// SetConsignmentStatus moves a consignment to a new status, returning
+// ErrInvalidTransition without calling the API if it cannot move there from
+// its current status.
+func (c *Client) SetConsignmentStatus(ctx context.Context, id, status string) (*Consignment, error) {
+	current, err := c.GetConsignment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-	// Use version to paginate through all pages
-	for len(data) > 2 {
-		page = []Consignment{}
-		data, v, err = c.ResourcePage(v, "GET", "consignments")
-		err = json.Unmarshal(data, &page)
-		consignments = append(consignments, page...)
+	from := ""
+	if current.Status != nil {
+		from = *current.Status
 	}
+	if !ValidConsignmentTransition(from, status) {
+		return nil, fmt.Errorf("%w: %s cannot move from %q to %q", ErrInvalidTransition, id, from, status)
+	}
+
+	return requestData[Consignment](ctx, c, "PUT", "consignments/"+url.PathEscape(id), ConsignmentRequest{Status: &status})
+}
+
+// SendConsignment marks a consignment as sent, e.g. an order sent to a supplier.
+func (c *Client) SendConsignment(ctx context.Context, id string) (*Consignment, error) {
+	return c.SetConsignmentStatus(ctx, id, ConsignmentSent)
+}
+
+// DispatchConsignment marks a consignment as dispatched from its source.
+func (c *Client) DispatchConsignment(ctx context.Context, id string) (*Consignment, error) {
+	return c.SetConsignmentStatus(ctx, id, ConsignmentDispatched)
+}
+
+// ReceiveConsignment marks a consignment as received, adding its received
+// products to the outlet's stock.
+func (c *Client) ReceiveConsignment(ctx context.Context, id string) (*Consignment, error) {
+	return c.SetConsignmentStatus(ctx, id, ConsignmentReceived)
+}
 
-	return consignments, err
+// CancelConsignment cancels a consignment.
+func (c *Client) CancelConsignment(ctx context.Context, id string) (*Consignment, error) {
+	return c.SetConsignmentStatus(ctx, id, ConsignmentCancelled)
+}
+
+// optional returns a pointer to s, or nil if s is empty.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
 }
@@ -2,9 +2,11 @@
 package vend
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"time"
+	"net/url"
+	"sync"
 )
 
 // Vend API Docs: https://docs.vendhq.com/v0.9/reference#consignments-2
@@ -21,56 +23,173 @@ type ConsignmentProduct struct {
 	SKU       *string `json:"product_sku,omitempty"`
 	Count     *string `json:"count,omitempty"`
 	Received  *string `json:"received,omitempty"`
-	Cost      *string `json:"cost,omitempty"`
+	Cost      *Money  `json:"cost,omitempty"`
 	// Name      *string    `json:"name,omitempty"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedAt *Time `json:"deleted_at,omitempty"`
 }
 
 // ConsignmentProducts gets all products inside Stock consignments and transfers from a store.
 func (c *Client) ConsignmentProducts(consignments *[]Consignment) ([]ConsignmentProduct, map[string][]ConsignmentProduct, error) {
+	return c.ConsignmentProductsContext(context.Background(), consignments)
+}
+
+// ConsignmentProductsContext is like ConsignmentProducts but stops when ctx is done.
+// Consignments are fetched concurrently; see StreamConsignmentProducts.
+func (c *Client) ConsignmentProductsContext(ctx context.Context, consignments *[]Consignment) ([]ConsignmentProduct, map[string][]ConsignmentProduct, error) {
 
-	// var err error
-	// var data response.Data
 	consignmentProducts := []ConsignmentProduct{}
 	consignmentProductMap := make(map[string][]ConsignmentProduct)
 
-	var URL string
+	// Stop the workers if we return early.
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
+	for result := range c.StreamConsignmentProducts(streamCtx, *consignments, defaultConsignmentWorkers) {
+		if result.Err != nil {
+			c.logger().ErrorContext(ctx, "getting consignment products", "consignment_id", result.ConsignmentID, "error", result.Err)
+			return []ConsignmentProduct{}, nil, result.Err
+		}
+		if len(result.Products) > 0 {
+			consignmentProductMap[result.ConsignmentID] = result.Products
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+
+	// Append each lot of consignment products to our list in consignment order.
 	for _, consignment := range *consignments {
+		if consignment.ID != nil {
+			consignmentProducts = append(consignmentProducts, consignmentProductMap[*consignment.ID]...)
+		}
+	}
 
-		response := ConsignmentProductPayload{}
-		data := response.Data
+	return consignmentProducts, consignmentProductMap, nil
+}
 
-		// Check and ignore cancelled consignments.
-		if *consignment.Status == "CANCELLED" {
-			continue
-		}
+// defaultConsignmentWorkers is how many consignments ConsignmentProducts
+// fetches at once.
+const defaultConsignmentWorkers = 4
+
+// ConsignmentProductsResult holds every product of one consignment, or the
+// error that stopped them being fetched.
+type ConsignmentProductsResult struct {
+	ConsignmentID string
+	Products      []ConsignmentProduct
+	Err           error
+}
 
-		// Build the URL for the consignment product page.
-		URL = c.urlFactory(0, *consignment.ID, "consignments")
+// StreamConsignmentProducts fetches every page of products for each
+// consignment, with up to workers consignments in flight at once, and sends
+// each consignment's products as soon as they are complete. Cancelled
+// consignments are skipped. Requests still go through the client's rate
+// limit, if one is set.
+//
+// The channel is closed once every consignment is done or ctx is cancelled,
+// and must be drained by the caller.
+func (c *Client) StreamConsignmentProducts(ctx context.Context, consignments []Consignment, workers int) <-chan ConsignmentProductsResult {
+	if workers < 1 {
+		workers = 1
+	}
 
-		body, _, err := c.MakeRequest("GET", URL, nil)
-		if err != nil {
-			fmt.Printf("Error getting resource: %s", err)
-		}
+	jobs := make(chan string)
+	results := make(chan ConsignmentProductsResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				products, err := collect[ConsignmentProduct](ctx, c, "consignments/"+url.PathEscape(id)+"/products", 0)
+				result := ConsignmentProductsResult{ConsignmentID: id, Products: products, Err: err}
+
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
 
-		// Decode the JSON into our defined consignment object.
-		err = json.Unmarshal(body, &response)
-		if err != nil {
-			fmt.Printf("\nError unmarshalling Vend consignment payload: %s", err)
-			return []ConsignmentProduct{}, nil, err
+	go func() {
+		defer close(jobs)
+		for _, consignment := range consignments {
+			// Check and ignore cancelled consignments.
+			if consignment.ID == nil || (consignment.Status != nil && *consignment.Status == ConsignmentCancelled) {
+				continue
+			}
+
+			select {
+			case jobs <- *consignment.ID:
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
 
-		// Data is an array of consignment product objects.
-		data = response.Data
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
 
-		for _, product := range data {
-			consignmentProductMap[*consignment.ID] = append(consignmentProductMap[*consignment.ID], product)
-		}
+	return results
+}
+
+// ConsignmentProductRequest holds the fields sent when adding a product to a
+// consignment or updating it. Only fields that are set are sent.
+type ConsignmentProductRequest struct {
+	ProductID *string  `json:"product_id,omitempty"`
+	Count     *float64 `json:"count,omitempty"`
+	Received  *float64 `json:"received,omitempty"`
+	Cost      *Money   `json:"cost,omitempty"`
+}
 
-		// Append each lot of consignment products to our list.
-		consignmentProducts = append(consignmentProducts, data...)
+// AddConsignmentProduct adds a product to a consignment.
+func (c *Client) AddConsignmentProduct(ctx context.Context, consignmentID string, r ConsignmentProductRequest) (*ConsignmentProduct, error) {
+	if isBlank(r.ProductID) {
+		return nil, errors.New("vend: consignment product ID is required")
+	}
+	if err := validConsignmentAmounts(r); err != nil {
+		return nil, err
 	}
 
-	return consignmentProducts, consignmentProductMap, nil
+	path := fmt.Sprintf("consignments/%s/products", url.PathEscape(consignmentID))
+	return requestData[ConsignmentProduct](ctx, c, "POST", path, r)
+}
+
+// UpdateConsignmentProduct changes the count, received amount or cost of a
+// product on a consignment.
+func (c *Client) UpdateConsignmentProduct(ctx context.Context, consignmentID, productID string, r ConsignmentProductRequest) (*ConsignmentProduct, error) {
+	if productID == "" {
+		return nil, errors.New("vend: consignment product ID is required")
+	}
+	if err := validConsignmentAmounts(r); err != nil {
+		return nil, err
+	}
+	r.ProductID = &productID
+
+	path := fmt.Sprintf("consignments/%s/products/%s", url.PathEscape(consignmentID), url.PathEscape(productID))
+	return requestData[ConsignmentProduct](ctx, c, "PUT", path, r)
+}
+
+// RemoveConsignmentProduct takes a product off a consignment.
+func (c *Client) RemoveConsignmentProduct(ctx context.Context, consignmentID, productID string) error {
+	path := fmt.Sprintf("consignments/%s/products/%s", url.PathEscape(consignmentID), url.PathEscape(productID))
+	_, _, err := c.MakeRequestContext(ctx, "DELETE", c.urlFactoryPath(path), nil)
+	return err
+}
+
+// validConsignmentAmounts checks a request does not set negative amounts.
+func validConsignmentAmounts(r ConsignmentProductRequest) error {
+	switch {
+	case r.Count != nil && *r.Count < 0:
+		return errors.New("vend: consignment product count must not be negative")
+	case r.Received != nil && *r.Received < 0:
+		return errors.New("vend: consignment product received must not be negative")
+	case r.Cost != nil && r.Cost.Sign() < 0:
+		return errors.New("vend: consignment product cost must not be negative")
+	}
+	return nil
 }
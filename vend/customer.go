@@ -2,8 +2,11 @@
 package vend
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
 )
 
 // Vend API Docs: https://docs.vendhq.com/v0.9/reference#customers-2
@@ -15,67 +18,266 @@ type CustomerSearchResponse struct {
 
 // Customer is a customer object.
 type Customer struct {
-	ID               *string  `json:"id,omitempty"`
-	Code             *string  `json:"customer_code,omitempty"`
-	FirstName        *string  `json:"first_name,omitempty"`
-	LastName         *string  `json:"last_name,omitempty"`
-	Email            *string  `json:"email,omitempty"`
-	YearToDate       *float64 `json:"year_to_date,omitempty"`
-	Balance          *float64 `json:"balance,omitempty"`
-	LoyaltyBalance   *float64 `json:"loyalty_balance,omitempty"`
-	Note             *string  `json:"note,omitempty"`
-	Gender           *string  `json:"gender,omitempty"`
-	DateOfBirth      *string  `json:"date_of_birth,omitempty"`
-	CompanyName      *string  `json:"company_name,omitempty"`
-	DoNotEmail       *bool    `json:"do_not_email,omitempty"`
-	Phone            *string  `json:"phone,omitempty"`
-	Mobile           *string  `json:"mobile,omitempty"`
-	Fax              *string  `json:"fax,omitempty"`
-	Twitter          *string  `json:"twitter,omitempty"`
-	Website          *string  `json:"website,omitempty"`
-	PhysicalSuburb   *string  `json:"physical_suburb,omitempty"`
-	PhysicalCity     *string  `json:"physical_city,omitempty"`
-	PhysicalPostcode *string  `json:"physical_postcode,omitempty"`
-	PhysicalState    *string  `json:"physical_state,omitempty"`
-	PostalSuburb     *string  `json:"postal_suburb,omitempty"`
-	PostalCity       *string  `json:"postal_city,omitempty"`
-	PostalState      *string  `json:"postal_state,omitempty"`
-	CreatedAt        *string  `json:"created_at,omitempty"`
-	PostalPostcode   *string  `json:"postal_postcode,omitempty"`
-	PhysicalAddress1 *string  `json:"physical_address_1,omitempty"`
-	PhysicalAddress2 *string  `json:"physical_address_2,omitempty"`
-	PostalAddress1   *string  `json:"postal_address_1,omitempty"`
-	PostalAddress2   *string  `json:"postal_address_2,omitempty"`
-	PostalCountryID  *string  `json:"postal_country_id,omitempty"`
-	CustomField1     *string  `json:"custom_field_1,omitempty"`
-	CustomField2     *string  `json:"custom_field_2,omitempty"`
-	CustomField3     *string  `json:"custom_field_3,omitempty"`
-	CustomField4     *string  `json:"custom_field_4,omitempty"`
-	DeletedAt        *string  `json:"deleted_at"`
+	ID               *string `json:"id,omitempty"`
+	Code             *string `json:"customer_code,omitempty"`
+	FirstName        *string `json:"first_name,omitempty"`
+	LastName         *string `json:"last_name,omitempty"`
+	Email            *string `json:"email,omitempty"`
+	YearToDate       *Money  `json:"year_to_date,omitempty"`
+	Balance          *Money  `json:"balance,omitempty"`
+	LoyaltyBalance   *Money  `json:"loyalty_balance,omitempty"`
+	EnableLoyalty    *bool   `json:"enable_loyalty,omitempty"`
+	CustomerGroupID  *string `json:"customer_group_id,omitempty"`
+	Note             *string `json:"note,omitempty"`
+	Gender           *string `json:"gender,omitempty"`
+	DateOfBirth      *string `json:"date_of_birth,omitempty"`
+	CompanyName      *string `json:"company_name,omitempty"`
+	DoNotEmail       *bool   `json:"do_not_email,omitempty"`
+	Phone            *string `json:"phone,omitempty"`
+	Mobile           *string `json:"mobile,omitempty"`
+	Fax              *string `json:"fax,omitempty"`
+	Twitter          *string `json:"twitter,omitempty"`
+	Website          *string `json:"website,omitempty"`
+	PhysicalSuburb   *string `json:"physical_suburb,omitempty"`
+	PhysicalCity     *string `json:"physical_city,omitempty"`
+	PhysicalPostcode *string `json:"physical_postcode,omitempty"`
+	PhysicalState    *string `json:"physical_state,omitempty"`
+	PostalSuburb     *string `json:"postal_suburb,omitempty"`
+	PostalCity       *string `json:"postal_city,omitempty"`
+	PostalState      *string `json:"postal_state,omitempty"`
+	CreatedAt        *Time   `json:"created_at,omitempty"`
+	PostalPostcode   *string `json:"postal_postcode,omitempty"`
+	PhysicalAddress1 *string `json:"physical_address_1,omitempty"`
+	PhysicalAddress2 *string `json:"physical_address_2,omitempty"`
+	PostalAddress1   *string `json:"postal_address_1,omitempty"`
+	PostalAddress2   *string `json:"postal_address_2,omitempty"`
+	PostalCountryID  *string `json:"postal_country_id,omitempty"`
+	CustomField1     *string `json:"custom_field_1,omitempty"`
+	CustomField2     *string `json:"custom_field_2,omitempty"`
+	CustomField3     *string `json:"custom_field_3,omitempty"`
+	CustomField4     *string `json:"custom_field_4,omitempty"`
+	DeletedAt        *Time   `json:"deleted_at"`
 }
 
 // Customers grabs and collates all customers in pages of 10,000.
 func (c *Client) Customers() ([]Customer, error) {
+	return c.CustomersContext(context.Background())
+}
+
+// CustomersContext is like Customers but stops when ctx is done.
+func (c *Client) CustomersContext(ctx context.Context) ([]Customer, error) {
+	return collect[Customer](ctx, c, "customers", 0)
+}
+
+// GetCustomer gets a single customer by ID.
+func (c *Client) GetCustomer(ctx context.Context, id string) (*Customer, error) {
+	return requestData[Customer](ctx, c, "GET", "customers/"+url.PathEscape(id), nil)
+}
+
+// GetCustomerByCode gets the customer with the given customer code.
+func (c *Client) GetCustomerByCode(ctx context.Context, code string) (*Customer, error) {
+	return searchOne[Customer](ctx, c, "customers", "customer_code", code)
+}
+
+// CustomerQuery filters a search for customers. Zero fields are not filtered on.
+type CustomerQuery struct {
+	Email string
+	Code  string
+	Phone string
+	// Name is matched loosely against the customer's name.
+	Name string
+	// Offset skips that many matching customers.
+	Offset int
+	// PageSize is how many results are asked for at a time. Vend's default
+	// is used if it is zero.
+	PageSize int
+	// OrderBy is the field to sort on, e.g. "last_name".
+	OrderBy string
+	// OrderDirection is "asc" or "desc".
+	OrderDirection string
+}
+
+// query builds the search query.
+func (q CustomerQuery) query() searchQuery {
+	return newSearchQuery("customers").
+		set("email", q.Email).
+		set("customer_code", q.Code).
+		set("phone", q.Phone).
+		set("q", q.Name).
+		order(q.OrderBy, q.OrderDirection)
+}
 
-	customers := []Customer{}
-	page := []Customer{}
+// SearchCustomers gets every customer matching the query.
+func (c *Client) SearchCustomers(ctx context.Context, q CustomerQuery) ([]Customer, error) {
+	return collectAll(ctx, c.SearchCustomersPager(q))
+}
+
+// SearchCustomersPager returns a pager over the customers matching the query.
+func (c *Client) SearchCustomersPager(q CustomerQuery) *SearchPager[Customer] {
+	return searchPager[Customer](c, q.query(), q.Offset, q.PageSize, 0)
+}
+
+// CustomerRequest holds the fields sent when creating or updating a
+// customer. Only fields that are set are sent, so a pointer to a zero value
+// clears a field while a nil pointer leaves it untouched.
+type CustomerRequest struct {
+	Code              *string `json:"customer_code,omitempty"`
+	FirstName         *string `json:"first_name,omitempty"`
+	LastName          *string `json:"last_name,omitempty"`
+	Email             *string `json:"email,omitempty"`
+	CustomerGroupID   *string `json:"customer_group_id,omitempty"`
+	EnableLoyalty     *bool   `json:"enable_loyalty,omitempty"`
+	Note              *string `json:"note,omitempty"`
+	Gender            *string `json:"gender,omitempty"`
+	DateOfBirth       *string `json:"date_of_birth,omitempty"`
+	CompanyName       *string `json:"company_name,omitempty"`
+	DoNotEmail        *bool   `json:"do_not_email,omitempty"`
+	Phone             *string `json:"phone,omitempty"`
+	Mobile            *string `json:"mobile,omitempty"`
+	Fax               *string `json:"fax,omitempty"`
+	Twitter           *string `json:"twitter,omitempty"`
+	Website           *string `json:"website,omitempty"`
+	PhysicalAddress1  *string `json:"physical_address_1,omitempty"`
+	PhysicalAddress2  *string `json:"physical_address_2,omitempty"`
+	PhysicalSuburb    *string `json:"physical_suburb,omitempty"`
+	PhysicalCity      *string `json:"physical_city,omitempty"`
+	PhysicalPostcode  *string `json:"physical_postcode,omitempty"`
+	PhysicalState     *string `json:"physical_state,omitempty"`
+	PhysicalCountryID *string `json:"physical_country_id,omitempty"`
+	PostalAddress1    *string `json:"postal_address_1,omitempty"`
+	PostalAddress2    *string `json:"postal_address_2,omitempty"`
+	PostalSuburb      *string `json:"postal_suburb,omitempty"`
+	PostalCity        *string `json:"postal_city,omitempty"`
+	PostalPostcode    *string `json:"postal_postcode,omitempty"`
+	PostalState       *string `json:"postal_state,omitempty"`
+	PostalCountryID   *string `json:"postal_country_id,omitempty"`
+	CustomField1      *string `json:"custom_field_1,omitempty"`
+	CustomField2      *string `json:"custom_field_2,omitempty"`
+	CustomField3      *string `json:"custom_field_3,omitempty"`
+	CustomField4      *string `json:"custom_field_4,omitempty"`
+}
+
+// CreateCustomer creates a customer and returns it as stored by Vend.
+func (c *Client) CreateCustomer(ctx context.Context, r CustomerRequest) (*Customer, error) {
+	if isBlank(r.FirstName) && isBlank(r.LastName) && isBlank(r.CompanyName) && isBlank(r.Email) {
+		return nil, errors.New("vend: a customer needs a name, company name or email")
+	}
+	return requestData[Customer](ctx, c, "POST", "customers", r)
+}
+
+// UpdateCustomer changes the fields set in r on an existing customer.
+func (c *Client) UpdateCustomer(ctx context.Context, id string, r CustomerRequest) (*Customer, error) {
+	if id == "" {
+		return nil, errors.New("vend: customer ID is required")
+	}
+	return requestData[Customer](ctx, c, "PUT", "customers/"+url.PathEscape(id), r)
+}
 
-	// v is a version that is used to get customers by page.
-	data, v, err := c.ResourcePage(0, "GET", "customers")
-	err = json.Unmarshal(data, &page)
+// DeleteCustomer deletes a customer.
+func (c *Client) DeleteCustomer(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("vend: customer ID is required")
+	}
+	_, _, err := c.MakeRequestContext(ctx, "DELETE", c.urlFactoryPath("customers/"+url.PathEscape(id)), nil)
+	return err
+}
+
+// MergeCustomers merges each of the duplicates into the target customer, so
+// their sales, balances and loyalty move to the target and the duplicates
+// are removed. The target is returned as it is after the merge.
+func (c *Client) MergeCustomers(ctx context.Context, targetID string, duplicateIDs ...string) (*Customer, error) {
+	if targetID == "" {
+		return nil, errors.New("vend: customer ID is required")
+	}
+
+	for _, id := range duplicateIDs {
+		if id == targetID {
+			return nil, fmt.Errorf("vend: cannot merge customer %s into itself", id)
+		}
+
+		body := struct {
+			CustomerID string `json:"customer_id"`
+		}{id}
+		path := fmt.Sprintf("customers/%s/actions/merge", url.PathEscape(targetID))
+		if _, _, err := c.MakeRequestContext(ctx, "POST", c.urlFactoryPath(path), body); err != nil {
+			return nil, fmt.Errorf("merging customer %s into %s: %w", id, targetID, err)
+		}
+	}
+
+	return c.GetCustomer(ctx, targetID)
+}
+
+// UpsertCustomer updates the customer with the same customer code or, failing
+// that, the same email as r, and creates one if there is no match. It
+// reports whether a customer was created.
+//
+// An error is returned rather than guessing if several customers share the email.
+func (c *Client) UpsertCustomer(ctx context.Context, r CustomerRequest) (*Customer, bool, error) {
+	match, err := c.matchCustomer(ctx, r)
 	if err != nil {
-		log.Printf("error while unmarshalling: %s", err)
+		return nil, false, err
 	}
 
-	customers = append(customers, page...)
+	if match == nil {
+		created, err := c.CreateCustomer(ctx, r)
+		return created, err == nil, err
+	}
 
-	// Use version to paginate through all pages
-	for len(page) > 0 {
-		page = []Customer{}
-		data, v, err = c.ResourcePage(v, "GET", "customers")
-		err = json.Unmarshal(data, &page)
-		customers = append(customers, page...)
+	updated, err := c.UpdateCustomer(ctx, *match.ID, r)
+	return updated, false, err
+}
+
+// matchCustomer finds the existing customer a request refers to, if any.
+func (c *Client) matchCustomer(ctx context.Context, r CustomerRequest) (*Customer, error) {
+	if !isBlank(r.Code) {
+		matches, err := c.SearchCustomers(ctx, CustomerQuery{Code: *r.Code})
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range liveCustomers(matches) {
+			if m.Code != nil && *m.Code == *r.Code {
+				return &m, nil
+			}
+		}
+	}
+
+	if !isBlank(r.Email) {
+		matches, err := c.SearchCustomers(ctx, CustomerQuery{Email: *r.Email})
+		if err != nil {
+			return nil, err
+		}
+
+		// Search matches loosely, so only keep exact email matches.
+		found := []Customer{}
+		for _, m := range liveCustomers(matches) {
+			if m.Email != nil && strings.EqualFold(*m.Email, *r.Email) {
+				found = append(found, m)
+			}
+		}
+		switch len(found) {
+		case 0:
+		case 1:
+			return &found[0], nil
+		default:
+			return nil, fmt.Errorf("vend: %d customers have the email %q", len(found), *r.Email)
+		}
 	}
 
-	return customers, err
+	return nil, nil
+}
+
+// liveCustomers drops deleted customers and those without an ID.
+func liveCustomers(customers []Customer) []Customer {
+	live := []Customer{}
+	for _, cu := range customers {
+		if cu.ID != nil && !isSet(cu.DeletedAt) {
+			live = append(live, cu)
+		}
+	}
+	return live
+}
+
+// isBlank reports whether an optional string is unset or empty.
+func isBlank(s *string) bool {
+	return s == nil || strings.TrimSpace(*s) == ""
 }
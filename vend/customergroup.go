@@ -0,0 +1,106 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Vend API Docs: https://docs.vendhq.com/reference#customer-groups
+
+// CustomerGroup is a group of customers, used to give them their own pricing
+// through price books.
+type CustomerGroup struct {
+	ID        *string `json:"id,omitempty"`
+	Name      *string `json:"name,omitempty"`
+	CreatedAt *Time   `json:"created_at,omitempty"`
+	UpdatedAt *Time   `json:"updated_at,omitempty"`
+	DeletedAt *Time   `json:"deleted_at,omitempty"`
+	Version   *int64  `json:"version,omitempty"`
+}
+
+// LoyaltySettings holds how a store rewards customers with loyalty.
+type LoyaltySettings struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	// EarnRate is the loyalty earned for each unit of currency spent.
+	EarnRate *float64 `json:"earn_rate,omitempty"`
+	// SignupBonus is the loyalty given to customers when they sign up.
+	SignupBonus *Money `json:"signup_bonus,omitempty"`
+}
+
+// CustomerGroups gets all customer groups from a store, along with a map of
+// them by ID for resolving the groups named on customers and price books.
+func (c *Client) CustomerGroups(ctx context.Context) ([]CustomerGroup, map[string]CustomerGroup, error) {
+	groups, err := collect[CustomerGroup](ctx, c, "customer_groups", 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groupMap := make(map[string]CustomerGroup)
+	for _, group := range groups {
+		if group.ID != nil {
+			groupMap[*group.ID] = group
+		}
+	}
+
+	return groups, groupMap, nil
+}
+
+// GetCustomerGroup gets a single customer group by ID.
+func (c *Client) GetCustomerGroup(ctx context.Context, id string) (*CustomerGroup, error) {
+	return requestData[CustomerGroup](ctx, c, "GET", "customer_groups/"+url.PathEscape(id), nil)
+}
+
+// CreateCustomerGroup creates a customer group with the given name.
+func (c *Client) CreateCustomerGroup(ctx context.Context, name string) (*CustomerGroup, error) {
+	if name == "" {
+		return nil, errors.New("vend: customer group name is required")
+	}
+	return requestData[CustomerGroup](ctx, c, "POST", "customer_groups", CustomerGroup{Name: &name})
+}
+
+// UpdateCustomerGroup renames a customer group.
+func (c *Client) UpdateCustomerGroup(ctx context.Context, id, name string) (*CustomerGroup, error) {
+	if id == "" {
+		return nil, errors.New("vend: customer group ID is required")
+	}
+	if name == "" {
+		return nil, errors.New("vend: customer group name is required")
+	}
+	return requestData[CustomerGroup](ctx, c, "PUT", "customer_groups/"+url.PathEscape(id), CustomerGroup{Name: &name})
+}
+
+// AssignCustomerGroup moves a customer into a customer group.
+func (c *Client) AssignCustomerGroup(ctx context.Context, customerID, groupID string) (*Customer, error) {
+	if groupID == "" {
+		return nil, errors.New("vend: customer group ID is required")
+	}
+	return c.UpdateCustomer(ctx, customerID, CustomerRequest{CustomerGroupID: &groupID})
+}
+
+// LoyaltySettings gets the store's loyalty settings.
+func (c *Client) LoyaltySettings(ctx context.Context) (*LoyaltySettings, error) {
+	return requestData[LoyaltySettings](ctx, c, "GET", "loyalty", nil)
+}
+
+// AdjustLoyalty adds amount to a customer's loyalty balance, or takes it off
+// if amount is negative, recording the reason against the adjustment.
+// The customer is returned with the new balance.
+func (c *Client) AdjustLoyalty(ctx context.Context, customerID string, amount Money, reason string) (*Customer, error) {
+	if customerID == "" {
+		return nil, errors.New("vend: customer ID is required")
+	}
+	if amount.IsZero() {
+		return nil, errors.New("vend: loyalty adjustment amount must not be zero")
+	}
+
+	body := struct {
+		Amount Money  `json:"amount"`
+		Note   string `json:"note,omitempty"`
+	}{amount, reason}
+
+	path := fmt.Sprintf("customers/%s/actions/adjust_loyalty", url.PathEscape(customerID))
+	return requestData[Customer](ctx, c, "POST", path, body)
+}
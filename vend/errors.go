@@ -0,0 +1,123 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors that an *APIError matches with errors.Is, based on its status code.
+var (
+	ErrUnauthorized = errors.New("vend: unauthorized")
+	ErrForbidden    = errors.New("vend: forbidden")
+	ErrNotFound     = errors.New("vend: not found")
+	ErrRateLimited  = errors.New("vend: rate limited")
+	ErrServer       = errors.New("vend: server error")
+)
+
+// APIError is returned when Vend responds with an unsuccessful status code.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+	Body       []byte
+	// Message is the error reported by Vend in the response body, if any.
+	Message string
+	// RetryAfter is how long Vend asked us to wait before trying again.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("vend: %s %s: %d %s", e.Method, e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Is reports whether the error matches one of the package's sentinel errors.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// newAPIError builds an APIError from an unsuccessful response and its body.
+func newAPIError(req *http.Request, resp *http.Response, body []byte) *APIError {
+	wait := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait = retryAfter(resp.Header, time.Now())
+	}
+
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     req.Method,
+		Endpoint:   req.URL.RequestURI(),
+		Body:       body,
+		Message:    errorMessage(body),
+		RetryAfter: wait,
+	}
+}
+
+// errorMessage pulls the human readable error out of a Vend error body.
+// Vend uses {"error": "...", "details": "..."} on both the 0.9 and 2.0 APIs.
+func errorMessage(body []byte) string {
+	var payload struct {
+		Error   interface{} `json:"error"`
+		Message string      `json:"message"`
+		Details interface{} `json:"details"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+
+	parts := []string{}
+	for _, v := range []interface{}{payload.Error, payload.Message, payload.Details} {
+		if s, ok := v.(string); ok && s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ": ")
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as
+// a date, returning zero if the header is missing or already in the past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs * float64(time.Second))
+	}
+
+	for _, layout := range []string{http.TimeFormat, time.RFC1123Z, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if d := t.Sub(now); d > 0 {
+				return d
+			}
+			return 0
+		}
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+// Package export turns Vend sales into a flattened sales ledger.
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvDateLayout is how dates are written, in the ledger's timezone.
+const csvDateLayout = "2006-01-02 15:04:05"
+
+// csvHeader names the ledger's columns.
+var csvHeader = []string{
+	"kind", "sale_id", "invoice_number", "status", "sale_date",
+	"outlet", "register", "user", "customer_code", "customer_name",
+	"line_id", "product_id", "sku", "product", "quantity", "unit_price",
+	"discount", "price", "tax_id", "tax", "total", "cost", "margin", "return",
+	"payment_id", "payment_type", "payment_date", "amount", "note",
+}
+
+// CSVWriter writes ledger rows as CSV, starting with a header row.
+type CSVWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+// NewCSVWriter returns a CSVWriter writing to w.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+// Write writes rows, after the header if this is the first call.
+func (cw *CSVWriter) Write(rows ...Row) error {
+	if !cw.header {
+		if err := cw.w.Write(csvHeader); err != nil {
+			return err
+		}
+		cw.header = true
+	}
+
+	for _, row := range rows {
+		if err := cw.w.Write(record(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes any buffered rows to the underlying writer.
+func (cw *CSVWriter) Flush() error {
+	if !cw.header {
+		// Write the header even when there were no rows.
+		if err := cw.Write(); err != nil {
+			return err
+		}
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// record formats a row in the column order of csvHeader. Columns that do
+// not apply to the row's kind are left blank.
+func record(row Row) []string {
+	values := map[string]string{
+		"kind":           row.Kind,
+		"sale_id":        row.SaleID,
+		"invoice_number": row.InvoiceNumber,
+		"status":         row.Status,
+		"sale_date":      date(row.SaleDate),
+		"outlet":         row.OutletName,
+		"register":       row.RegisterName,
+		"user":           row.UserName,
+		"customer_code":  row.CustomerCode,
+		"customer_name":  row.CustomerName,
+		"note":           row.Note,
+	}
+
+	switch row.Kind {
+	case KindLine:
+		values["line_id"] = row.LineID
+		values["product_id"] = row.ProductID
+		values["sku"] = row.SKU
+		values["product"] = row.ProductName
+		values["quantity"] = strconv.FormatFloat(row.Quantity, 'f', -1, 64)
+		values["unit_price"] = row.UnitPrice.String()
+		values["discount"] = row.Discount.String()
+		values["price"] = row.Price.String()
+		values["tax_id"] = row.TaxID
+		values["tax"] = row.Tax.String()
+		values["total"] = row.Total.String()
+		values["cost"] = row.Cost.String()
+		values["margin"] = row.Margin.String()
+		values["return"] = strconv.FormatBool(row.Return)
+	case KindPayment:
+		values["payment_id"] = row.PaymentID
+		values["payment_type"] = row.PaymentType
+		values["payment_date"] = date(row.PaymentDate)
+		values["amount"] = row.Amount.String()
+	}
+
+	rec := make([]string, len(csvHeader))
+	for i, column := range csvHeader {
+		rec[i] = values[column]
+	}
+	return rec
+}
+
+// date formats t, or leaves it blank if it is zero.
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(csvDateLayout)
+}
@@ -0,0 +1,46 @@
+// Package export turns Vend sales into a flattened sales ledger.
+package export
+
+import (
+	"context"
+	"io"
+
+	"github.com/jackharrisonsherlock/govend/vend"
+)
+
+// Export writes the ledger of the sales made in period to w as CSV, with
+// dates in the store's timezone. Sales are streamed a page at a time, so
+// only the lookups are held in memory.
+func Export(ctx context.Context, c *vend.Client, period vend.Period, w io.Writer) error {
+	clock, err := c.Clock()
+	if err != nil {
+		return err
+	}
+	lookups, err := LoadLookups(ctx, c)
+	if err != nil {
+		return err
+	}
+	ledger := Ledger{Lookups: lookups, Location: clock.Location()}
+
+	version, err := c.VersionAt(ctx, "sales", period.Start)
+	if err != nil {
+		return err
+	}
+
+	cw := NewCSVWriter(w)
+	err = c.StreamSales(ctx, version, func(sales []vend.Sale, _ int64) error {
+		for _, sale := range sales {
+			if sale.SaleDate == nil || !period.Contains(sale.SaleDate.Time) || !ledger.Include(sale) {
+				continue
+			}
+			if err := cw.Write(ledger.Rows(sale)...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return cw.Flush()
+}
@@ -0,0 +1,283 @@
+// Package export turns Vend sales into a flattened sales ledger.
+package export
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackharrisonsherlock/govend/vend"
+)
+
+// Row kinds.
+const (
+	KindLine    = "line"
+	KindPayment = "payment"
+)
+
+// Row is one line of the ledger: either a product sold on a sale or a
+// payment taken for it. The sale's fields are repeated on every row.
+type Row struct {
+	Kind          string
+	SaleID        string
+	InvoiceNumber string
+	Status        string
+	SaleDate      time.Time
+	OutletID      string
+	OutletName    string
+	RegisterID    string
+	RegisterName  string
+	UserID        string
+	UserName      string
+	CustomerID    string
+	CustomerCode  string
+	CustomerName  string
+	Note          string
+
+	// Line item rows. Amounts are totals for the line excluding tax unless
+	// named otherwise.
+	LineID      string
+	ProductID   string
+	SKU         string
+	ProductName string
+	Quantity    float64
+	UnitPrice   vend.Money
+	Discount    vend.Money
+	Price       vend.Money
+	TaxID       string
+	Tax         vend.Money
+	// Total is the price including tax.
+	Total  vend.Money
+	Cost   vend.Money
+	Margin vend.Money
+	Return bool
+
+	// Payment rows.
+	PaymentID   string
+	PaymentType string
+	PaymentDate time.Time
+	Amount      vend.Money
+}
+
+// Lookups resolves the IDs on sales to the objects they refer to.
+type Lookups struct {
+	Products  map[string]vend.Product
+	Outlets   map[string]vend.Outlet
+	Registers map[string]vend.Register
+	Users     map[string]vend.User
+	Customers map[string]vend.Customer
+}
+
+// NewLookups indexes the given objects by ID.
+func NewLookups(products []vend.Product, outlets []vend.Outlet, registers []vend.Register, users []vend.User, customers []vend.Customer) *Lookups {
+	return &Lookups{
+		Products:  index(products, func(p vend.Product) *string { return p.ID }),
+		Outlets:   index(outlets, func(o vend.Outlet) *string { return o.ID }),
+		Registers: index(registers, func(r vend.Register) *string { return r.ID }),
+		Users:     index(users, func(u vend.User) *string { return u.ID }),
+		Customers: index(customers, func(c vend.Customer) *string { return c.ID }),
+	}
+}
+
+// LoadLookups gets the products, outlets, registers, users and customers
+// of a store.
+func LoadLookups(ctx context.Context, c *vend.Client) (*Lookups, error) {
+	products, _, err := c.ProductsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	outlets, _, err := c.OutletsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	registers, err := c.RegistersContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users, err := c.UsersContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	customers, err := c.CustomersContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLookups(products, outlets, registers, users, customers), nil
+}
+
+// index maps objects by their ID, skipping those without one.
+func index[T any](items []T, id func(T) *string) map[string]T {
+	m := make(map[string]T, len(items))
+	for _, item := range items {
+		if key := id(item); key != nil {
+			m[*key] = item
+		}
+	}
+	return m
+}
+
+// Ledger flattens sales into ledger rows.
+type Ledger struct {
+	Lookups *Lookups
+	// Location is the timezone dates are given in. UTC is used if nil.
+	Location *time.Location
+}
+
+// Include reports whether a sale belongs in the ledger. Voided and deleted
+// sales are left out.
+func (l Ledger) Include(sale vend.Sale) bool {
+	if sale.DeletedAt != nil && !sale.DeletedAt.IsZero() {
+		return false
+	}
+	return str(sale.Status) != vend.SaleVoided
+}
+
+// Rows returns a row for each line item on the sale followed by a row for
+// each payment. Voided line items are left out.
+func (l Ledger) Rows(sale vend.Sale) []Row {
+	base := l.saleRow(sale)
+
+	rows := []Row{}
+	if sale.LineItems != nil {
+		for _, line := range *sale.LineItems {
+			if str(line.Status) == "VOIDED" {
+				continue
+			}
+			rows = append(rows, l.lineRow(base, line))
+		}
+	}
+	if sale.Payments != nil {
+		for _, payment := range *sale.Payments {
+			rows = append(rows, l.paymentRow(base, payment))
+		}
+	}
+	return rows
+}
+
+// saleRow fills in the fields shared by every row of a sale.
+func (l Ledger) saleRow(sale vend.Sale) Row {
+	row := Row{
+		SaleID:        str(sale.ID),
+		InvoiceNumber: str(sale.InvoiceNumber),
+		Status:        str(sale.Status),
+		SaleDate:      l.in(sale.SaleDate),
+		OutletID:      str(sale.OutletID),
+		RegisterID:    str(sale.RegisterID),
+		UserID:        str(sale.UserID),
+		CustomerID:    str(sale.CustomerID),
+		Note:          str(sale.Note),
+	}
+
+	lookups := l.lookups()
+	if outlet, ok := lookups.Outlets[row.OutletID]; ok {
+		row.OutletName = str(outlet.Name)
+	}
+	if register, ok := lookups.Registers[row.RegisterID]; ok {
+		row.RegisterName = str(register.Name)
+	}
+	if user, ok := lookups.Users[row.UserID]; ok {
+		row.UserName = firstNonEmpty(str(user.DisplayName), str(user.Username))
+	}
+	if customer, ok := lookups.Customers[row.CustomerID]; ok {
+		row.CustomerCode = str(customer.Code)
+		name := strings.TrimSpace(str(customer.FirstName) + " " + str(customer.LastName))
+		row.CustomerName = firstNonEmpty(name, str(customer.CompanyName))
+	}
+	return row
+}
+
+// lineRow builds the row for a line item. Totals sent by Vend are used
+// where present, otherwise they are worked out from the unit amounts.
+func (l Ledger) lineRow(row Row, line vend.LineItem) Row {
+	row.Kind = KindLine
+	row.LineID = str(line.ID)
+	row.ProductID = str(line.ProductID)
+	row.TaxID = str(line.TaxID)
+	row.Return = line.IsReturn != nil && *line.IsReturn
+	if line.Quantity != nil {
+		row.Quantity = *line.Quantity
+	}
+
+	row.UnitPrice = money(line.UnitPrice, line.Price)
+	row.Price = total(row.Quantity, []*vend.Money{line.TotalPrice, line.PriceTotal}, line.UnitPrice, line.Price)
+	row.Discount = total(row.Quantity, []*vend.Money{line.TotalDiscount, line.DiscountTotal}, line.UnitDiscount, line.Discount)
+	row.Tax = total(row.Quantity, []*vend.Money{line.TotalTax, line.TaxTotal}, line.UnitTax, line.Tax)
+	row.Cost = total(row.Quantity, []*vend.Money{line.TotalCost, line.CostTotal}, line.UnitCost, line.Cost)
+	row.Total = row.Price.Add(row.Tax)
+	row.Margin = row.Price.Sub(row.Cost)
+
+	if product, ok := l.lookups().Products[row.ProductID]; ok {
+		row.SKU = str(product.SKU)
+		row.ProductName = firstNonEmpty(str(product.VariantName), str(product.Name))
+	}
+	return row
+}
+
+// paymentRow builds the row for a payment.
+func (l Ledger) paymentRow(row Row, payment vend.Payment) Row {
+	row.Kind = KindPayment
+	row.PaymentID = str(payment.ID)
+	row.PaymentType = str(payment.Name)
+	row.PaymentDate = l.in(payment.PaymentDate)
+	row.Amount = money(payment.Amount)
+	return row
+}
+
+// lookups returns the ledger's lookups, or empty ones if none were given.
+func (l Ledger) lookups() *Lookups {
+	if l.Lookups == nil {
+		return &Lookups{}
+	}
+	return l.Lookups
+}
+
+// in converts a timestamp to the ledger's timezone.
+func (l Ledger) in(t *vend.Time) time.Time {
+	if t == nil || t.IsZero() {
+		return time.Time{}
+	}
+	if l.Location == nil {
+		return t.UTC()
+	}
+	return t.In(l.Location)
+}
+
+// total returns the first of the totals that is set, or else the first of
+// the unit amounts that is set times the quantity.
+func total(quantity float64, totals []*vend.Money, units ...*vend.Money) vend.Money {
+	for _, t := range totals {
+		if t != nil {
+			return *t
+		}
+	}
+	return money(units...).Mul(quantity)
+}
+
+// money returns the first of the amounts that is set, or zero.
+func money(amounts ...*vend.Money) vend.Money {
+	for _, m := range amounts {
+		if m != nil {
+			return *m
+		}
+	}
+	return vend.Money{}
+}
+
+// str dereferences an optional string.
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// firstNonEmpty returns the first of the strings that is not empty.
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
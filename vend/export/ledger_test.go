@@ -0,0 +1,197 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackharrisonsherlock/govend/vend"
+)
+
+// loadSale decodes the sale fixture.
+func loadSale(t *testing.T) vend.Sale {
+	t.Helper()
+	data, err := os.ReadFile("../../fixtures/sale.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sale := vend.Sale{}
+	if err := json.Unmarshal(data, &sale); err != nil {
+		t.Fatal(err)
+	}
+	return sale
+}
+
+// testLedger resolves the IDs on the sale fixture.
+func testLedger() Ledger {
+	id := func(s string) *string { return &s }
+	return Ledger{
+		Lookups: NewLookups(
+			[]vend.Product{{ID: id("0624dbcd-ef13-11e6-e986-ecd5a6bd5f46"), SKU: id("10001"), Name: id("Coffee")}},
+			[]vend.Outlet{{ID: id("0624dbcd-ef95-11e6-e986-ecd5a64d7fa9"), Name: id("Main Street")}},
+			[]vend.Register{{ID: id("0624dbcd-ef95-11e6-e986-ecd5a64ee59b"), Name: id("Main Register")}},
+			[]vend.User{{ID: id("0624dbcd-ef95-11e6-e986-ecd5a6503836"), Username: id("jo")}},
+			[]vend.Customer{{ID: id("0624dbcd-ef95-11e6-e986-ecd5a6420648"), Code: id("WALKIN"), FirstName: id("Walk"), LastName: id("In")}},
+		),
+		Location: time.FixedZone("NZDT", 13*60*60),
+	}
+}
+
+// writeCSV writes the ledger rows of a sale as CSV and reads them back as
+// one map of column to value per row.
+func writeCSV(t *testing.T, l Ledger, sale vend.Sale) []map[string]string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w := NewCSVWriter(buf)
+	if err := w.Write(l.Rows(sale)...); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := []map[string]string{}
+	for _, rec := range records[1:] {
+		row := map[string]string{}
+		for i, column := range records[0] {
+			row[column] = rec[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// checkRow reports each column of got that differs from want. Columns not
+// in want must be blank.
+func checkRow(t *testing.T, got, want map[string]string) {
+	t.Helper()
+	for _, column := range csvHeader {
+		if got[column] != want[column] {
+			t.Errorf("%s row %s = %q, want %q", got["kind"], column, got[column], want[column])
+		}
+	}
+}
+
+// saleColumns are the columns filled in on every row of the sale fixture.
+func saleColumns(kind string, columns map[string]string) map[string]string {
+	row := map[string]string{
+		"kind":           kind,
+		"sale_id":        "9cd85b39-eff0-8152-11e6-ecd601f1e2da",
+		"invoice_number": "1",
+		"status":         "CLOSED",
+		"sale_date":      "2017-02-07 14:37:58",
+		"outlet":         "Main Street",
+		"register":       "Main Register",
+		"user":           "jo",
+		"customer_code":  "WALKIN",
+		"customer_name":  "Walk In",
+	}
+	for column, value := range columns {
+		row[column] = value
+	}
+	return row
+}
+
+func TestLedgerCSV(t *testing.T) {
+	rows := writeCSV(t, testLedger(), loadSale(t))
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want a line and a payment", len(rows))
+	}
+
+	checkRow(t, rows[0], saleColumns(KindLine, map[string]string{
+		"line_id":    "9cd85b39-eff0-8152-11e6-ecd6075bb07d",
+		"product_id": "0624dbcd-ef13-11e6-e986-ecd5a6bd5f46",
+		"sku":        "10001",
+		"product":    "Coffee",
+		"quantity":   "1",
+		"unit_price": "43.47826",
+		"discount":   "0.00",
+		"price":      "43.47826",
+		"tax_id":     "0624dbcd-ef95-11e6-e986-ecd5a645338e",
+		"tax":        "6.52174",
+		"total":      "50.00",
+		"cost":       "10.00",
+		"margin":     "33.47826",
+		"return":     "false",
+	}))
+	checkRow(t, rows[1], saleColumns(KindPayment, map[string]string{
+		"payment_id":   "9cd85b39-eff0-8152-11e6-ecd60e250e1e",
+		"payment_type": "Cash",
+		"payment_date": "2017-02-07 14:37:58",
+		"amount":       "50.00",
+	}))
+}
+
+func TestLedgerUnitAmounts(t *testing.T) {
+	// Without totals, the line's amounts are the unit amounts times the
+	// quantity.
+	sale := loadSale(t)
+	line := &(*sale.LineItems)[0]
+	line.TotalPrice, line.PriceTotal = nil, nil
+	line.TotalTax, line.TaxTotal = nil, nil
+	line.TotalCost, line.CostTotal = nil, nil
+	line.TotalDiscount, line.DiscountTotal = nil, nil
+	unitDiscount := vend.MustParseMoney("1.5")
+	line.UnitDiscount = &unitDiscount
+	quantity := 3.0
+	line.Quantity = &quantity
+
+	row := testLedger().Rows(sale)[0]
+	tests := []struct {
+		column string
+		got    vend.Money
+		want   string
+	}{
+		{"price", row.Price, "130.43478"},
+		{"discount", row.Discount, "4.50"},
+		{"tax", row.Tax, "19.56522"},
+		{"total", row.Total, "150.00"},
+		{"cost", row.Cost, "30.00"},
+		{"margin", row.Margin, "100.43478"},
+	}
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%s = %s, want %s", tt.column, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLedgerSkipsVoided(t *testing.T) {
+	sale := loadSale(t)
+	voided := (*sale.LineItems)[0]
+	status := "VOIDED"
+	voided.Status = &status
+	lines := append(*sale.LineItems, voided)
+	sale.LineItems = &lines
+
+	l := testLedger()
+	lineRows := 0
+	for _, row := range l.Rows(sale) {
+		if row.Kind == KindLine {
+			lineRows++
+		}
+	}
+	if lineRows != 1 {
+		t.Errorf("got %d line rows, want the voided line left out", lineRows)
+	}
+
+	if !l.Include(sale) {
+		t.Error("closed sale left out of the ledger")
+	}
+	sale.Status = &status
+	if l.Include(sale) {
+		t.Error("voided sale included in the ledger")
+	}
+	sale = loadSale(t)
+	sale.DeletedAt = &vend.Time{Time: time.Now()}
+	if l.Include(sale) {
+		t.Error("deleted sale included in the ledger")
+	}
+}
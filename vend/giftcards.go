@@ -2,8 +2,10 @@
 package vend
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
 )
 
 // GiftCardPayload hold Gift Card data
@@ -16,65 +18,65 @@ type GiftCard struct {
 	ID                   *string               `json:"id"`
 	Number               *string               `json:"number"`
 	SaleID               *string               `json:"sale_id"`
-	CreatedAt            *string               `json:"created_at"`
-	ExpiresAt            *string               `json:"expires_at"`
+	CreatedAt            *Time                 `json:"created_at"`
+	ExpiresAt            *Time                 `json:"expires_at"`
 	Status               *string               `json:"status"`
-	Balance              *float64              `json:"balance"`
-	TotalSold            *float64              `json:"total_sold"`
-	TotalRedeemed        *float64              `json:"total_redeemed"`
+	Balance              *Money                `json:"balance"`
+	TotalSold            *Money                `json:"total_sold"`
+	TotalRedeemed        *Money                `json:"total_redeemed"`
 	GiftCardTransactions []GiftCardTransaction `json:"gift_card_transactions"`
 }
 
 // GiftCardTransaction is a Gift Card object.
 type GiftCardTransaction struct {
-	ID        *string  `json:"id"`
-	Amount    *float64 `json:"amount"`
-	Type      *string  `json:"type"`
-	UserID    *string  `json:"user_id"`
-	CreatedAt *string  `json:"created_at"`
+	ID        *string `json:"id"`
+	Amount    *Money  `json:"amount"`
+	Type      *string `json:"type"`
+	UserID    *string `json:"user_id"`
+	CreatedAt *Time   `json:"created_at"`
 }
 
 // GiftCards gets all gift card data from a store.
 func (c *Client) GiftCards() ([]GiftCard, error) {
+	return c.GiftCardsContext(context.Background())
+}
 
-	giftcards := []GiftCard{}
-	payload := GiftCardPayload{}
-
-	// Here we get the first page.
-	data, lastID, err := c.ResourcePageFlake("", "GET", "balances/gift_cards")
-	if err != nil {
-		return []GiftCard{}, fmt.Errorf("Failed to retrieve a page of data %v", err)
-	}
-
-	// Unmarshal payload into Gift Card object.
-	err = json.Unmarshal(data, &payload)
+// GiftCardsContext is like GiftCards but stops when ctx is done.
+func (c *Client) GiftCardsContext(ctx context.Context) ([]GiftCard, error) {
+	giftcards, err := collectAll(ctx, c.giftCardPager())
 	if err != nil {
-		return []GiftCard{}, err
+		return nil, fmt.Errorf("Failed to retrieve a page of data %w", err)
 	}
 
-	// Append page to list.
-	giftcards = append(giftcards, payload.Data...)
-
-	// NOTE: Turns out empty response is 2bytes.
-	for len(payload.Data) > 1 {
-		payload = GiftCardPayload{}
+	return giftcards, nil
+}
 
-		// Continue grabbing pages until we receive an empty one.
-		data, lastID, err = c.ResourcePageFlake(lastID, "GET", "balances/gift_cards")
-		if err != nil {
-			return nil, err
+// giftCardPager pages through gift cards by Flake ID.
+func (c *Client) giftCardPager() *CursorPager[GiftCard] {
+	return NewCursorPager(c, "balances/gift_cards", Before, func(g GiftCard) string {
+		if g.ID == nil {
+			return ""
 		}
+		return *g.ID
+	})
+}
 
-		err = json.Unmarshal(data, &payload)
-		if err != nil {
-			return []GiftCard{}, err
-		}
+// GetGiftCard gets a single gift card by its card number, which is how
+// Vend addresses a gift card rather than by its ID.
+func (c *Client) GetGiftCard(ctx context.Context, number string) (*GiftCard, error) {
+	return requestData[GiftCard](ctx, c, "GET", "balances/gift_cards/"+url.PathEscape(number), nil)
+}
 
-		// Last page will always return a gift card from the previous payload, removes the last gift card.
-		if len(payload.Data) > 1 {
-			giftcards = append(giftcards, payload.Data...)
-		}
+// GetGiftCardByNumber gets the gift card with the given card number in a
+// single request, returning ErrNotFound if there is none.
+func (c *Client) GetGiftCardByNumber(ctx context.Context, number string) (*GiftCard, error) {
+	if number == "" {
+		return nil, errors.New("vend: gift card number is required")
 	}
 
-	return giftcards, err
+	g, err := c.GetGiftCard(ctx, number)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: no gift card with number %q", ErrNotFound, number)
+	}
+	return g, err
 }
@@ -0,0 +1,217 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxImageSize is the largest image Vend accepts for upload.
+const maxImageSize = 10 << 20
+
+// ImageUploadResult is the outcome of uploading one image in a bulk upload.
+type ImageUploadResult struct {
+	Upload    ProductUpload
+	ProductID string
+	Image     *ImageUpload
+	Err       error
+}
+
+// UploadProductImage uploads an image read from r to a product. The
+// filename is sent to Vend so it can tell the image format. Images larger
+// than Vend's 10MB limit are rejected without being read in full.
+func (c *Client) UploadProductImage(ctx context.Context, productID string, r io.Reader, filename string) (*ImageUpload, error) {
+	if productID == "" {
+		return nil, errors.New("vend: product ID is required")
+	}
+
+	// Buffer the whole form so the request can be retried.
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("image", filename)
+	if err != nil {
+		return nil, err
+	}
+	n, err := io.Copy(part, io.LimitReader(r, maxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading image %s: %w", filename, err)
+	}
+	if n > maxImageSize {
+		return nil, fmt.Errorf("vend: image %s is larger than %d bytes", filename, maxImageSize)
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, "POST", c.ImageUploadURLFactory(productID), form.FormDataContentType(), body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &ImageUpload{}
+	if err := json.Unmarshal(data, upload); err != nil {
+		return nil, fmt.Errorf("decoding image upload response: %w", err)
+	}
+
+	return upload, nil
+}
+
+// UploadProductImageFile uploads an image stored on disk to a product.
+func (c *Client) UploadProductImageFile(ctx context.Context, productID, filePath string) (*ImageUpload, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return c.UploadProductImage(ctx, productID, f, filepath.Base(filePath))
+}
+
+// UploadProductImageURL downloads an image and uploads it to a product.
+// The download must be served with an image/* Content-Type.
+func (c *Client) UploadProductImageURL(ctx context.Context, productID, imageURL string) (*ImageUpload, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing image URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.config().httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading image %s: %w", imageURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("downloading image %s: status %d", imageURL, resp.StatusCode)
+	}
+	if resp.ContentLength > maxImageSize {
+		return nil, fmt.Errorf("vend: image %s is larger than %d bytes", imageURL, maxImageSize)
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "image/") {
+		return nil, fmt.Errorf("vend: %s is not an image: Content-Type %q", imageURL, resp.Header.Get("Content-Type"))
+	}
+
+	filename := path.Base(u.Path)
+	if filename == "" || filename == "/" || filename == "." {
+		filename = "image"
+	}
+
+	return c.UploadProductImage(ctx, productID, resp.Body, filename)
+}
+
+// UploadProductImages uploads the image URL of each upload to the product
+// it names by ID, SKU or handle, in that order of preference. A handle
+// matches the parent product of a variant family.
+//
+// The outcome of each upload is returned in order; the error is only set if
+// the store's products could not be fetched to resolve SKUs and handles.
+func (c *Client) UploadProductImages(ctx context.Context, uploads []ProductUpload) ([]ImageUploadResult, error) {
+
+	var bySKU, byHandle map[string]string
+	for _, u := range uploads {
+		if u.ID != "" {
+			continue
+		}
+
+		// Only fetch the catalogue if something needs resolving.
+		products, _, err := c.ProductsContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		bySKU, byHandle = productLookups(products)
+		break
+	}
+
+	results := make([]ImageUploadResult, 0, len(uploads))
+	for _, u := range uploads {
+		result := ImageUploadResult{Upload: u, ProductID: u.ID}
+		if result.ProductID == "" {
+			if id, ok := bySKU[u.SKU]; ok && u.SKU != "" {
+				result.ProductID = id
+			} else if id, ok := byHandle[u.Handle]; ok && u.Handle != "" {
+				result.ProductID = id
+			}
+		}
+
+		switch {
+		case result.ProductID == "":
+			result.Err = fmt.Errorf("vend: no product with SKU %q or handle %q", u.SKU, u.Handle)
+		case u.ImageURL == "":
+			result.Err = errors.New("vend: image URL is required")
+		default:
+			result.Image, result.Err = c.UploadProductImageURL(ctx, result.ProductID, u.ImageURL)
+		}
+		if result.Err != nil && ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// productLookups maps SKUs and handles to product IDs. Handles map to the
+// parent of a variant family where there is one.
+func productLookups(products []Product) (map[string]string, map[string]string) {
+	bySKU := make(map[string]string)
+	byHandle := make(map[string]string)
+
+	for _, p := range products {
+		if p.ID == nil || isSet(p.DeletedAt) {
+			continue
+		}
+		if p.SKU != nil {
+			bySKU[*p.SKU] = *p.ID
+		}
+		if p.Handle != nil {
+			_, seen := byHandle[*p.Handle]
+			if !seen || p.VariantParentID == nil {
+				byHandle[*p.Handle] = *p.ID
+			}
+		}
+	}
+
+	return bySKU, byHandle
+}
+
+// ReorderProductImage moves an image to the given position on its product.
+func (c *Client) ReorderProductImage(ctx context.Context, imageID string, position int64) (*ImageUpload, error) {
+	body := struct {
+		Position int64 `json:"position"`
+	}{position}
+
+	data, err := requestData[Data](ctx, c, "PUT", "product_images/"+url.PathEscape(imageID), body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImageUpload{Data: *data}, nil
+}
+
+// DeleteProductImage removes an image from its product.
+func (c *Client) DeleteProductImage(ctx context.Context, imageID string) error {
+	_, _, err := c.MakeRequestContext(ctx, "DELETE", c.urlFactoryPath("product_images/"+url.PathEscape(imageID)), nil)
+	return err
+}
@@ -0,0 +1,24 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardLogger is used when no logger has been configured, so the package
+// never writes anything on its own.
+var discardLogger = slog.New(discardHandler{})
+
+// discardHandler is a slog.Handler that drops every record.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// logger returns the client's logger, tagged with the store it talks to.
+func (c *Client) logger() *slog.Logger {
+	return c.config().logger.With("domain_prefix", c.DomainPrefix)
+}
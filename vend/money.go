@@ -0,0 +1,374 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// moneyScale is the number of decimal places Money holds. Vend sends
+// amounts to at most five places, e.g. unit prices excluding tax.
+const moneyScale = 6
+
+// microsPerUnit is the number of millionths in one unit of currency.
+const microsPerUnit = 1_000_000
+
+// decimalPattern matches a decimal amount with an optional exponent, as
+// in a JSON number, but not base prefixes or fractions.
+var decimalPattern = regexp.MustCompile(`^[+-]?\d+(\.\d+)?(?:[eE]([+-]?\d+))?$`)
+
+// maxMoneyExponent bounds the exponent of an amount such as "1.0e-5".
+// Amounts with larger exponents round to zero or do not fit in Money, and
+// are rejected before big.Rat builds numbers that size.
+const maxMoneyExponent = 30
+
+// Money is an exact decimal amount of money, held as a whole number of
+// millionths of a unit so that sums never drift the way float64 does.
+// It covers amounts up to about ±9.2 trillion. The zero value is zero.
+//
+// Money decodes from JSON numbers and numeric strings, and encodes as a
+// JSON number.
+type Money struct {
+	micros int64
+}
+
+// RoundingMode decides which way an amount between two steps is rounded.
+type RoundingMode int
+
+// Rounding modes.
+const (
+	// RoundHalfEven rounds to the nearest step, and halves to the even step.
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest step, and halves away from zero.
+	RoundHalfUp
+	// RoundDown rounds towards zero.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+	// RoundFloor rounds towards negative infinity.
+	RoundFloor
+	// RoundCeiling rounds towards positive infinity.
+	RoundCeiling
+)
+
+// NewMoney returns v × 10^exp, e.g. NewMoney(4348, -2) is 43.48.
+// Digits beyond six decimal places are rounded half to even.
+func NewMoney(v int64, exp int) Money {
+	r := new(big.Rat).SetInt64(v)
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exp))), nil))
+	if exp < 0 {
+		r.Quo(r, pow)
+	} else {
+		r.Mul(r, pow)
+	}
+	m, _ := moneyFromRat(r, RoundHalfEven)
+	return m
+}
+
+// ParseMoney reads a decimal amount such as "43.4782600000", "-5" or
+// "1.0e-5". Other number formats and exponents beyond ±30 are rejected.
+// Digits beyond six decimal places are rounded half to even.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	match := decimalPattern.FindStringSubmatch(s)
+	if match == nil {
+		return Money{}, fmt.Errorf("vend: invalid money amount %q", s)
+	}
+	if exp := match[2]; exp != "" {
+		if n, err := strconv.Atoi(exp); err != nil || abs(n) > maxMoneyExponent {
+			return Money{}, fmt.Errorf("vend: money amount %q out of range", s)
+		}
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Money{}, fmt.Errorf("vend: invalid money amount %q", s)
+	}
+	m, ok := moneyFromRat(r, RoundHalfEven)
+	if !ok {
+		return Money{}, fmt.Errorf("vend: money amount %q out of range", s)
+	}
+	return m, nil
+}
+
+// MustParseMoney is like ParseMoney but panics if s is not a valid amount.
+// It is meant for amounts written into code.
+func MustParseMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// MoneyFromFloat converts a float64 to the nearest millionth, for amounts
+// that only exist as floats such as those from older code.
+func MoneyFromFloat(f float64) Money {
+	m, err := ParseMoney(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Money{}
+	}
+	return m
+}
+
+// moneyFromRat rounds r to the nearest millionth using mode, reporting
+// false if it does not fit.
+func moneyFromRat(r *big.Rat, mode RoundingMode) (Money, bool) {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt64(microsPerUnit))
+	q := roundRat(scaled, mode)
+	if !q.IsInt64() {
+		return Money{}, false
+	}
+	return Money{micros: q.Int64()}, true
+}
+
+// roundRat rounds r to a whole number using mode.
+func roundRat(r *big.Rat, mode RoundingMode) *big.Int {
+	q, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return q
+	}
+
+	// How the remainder compares with half the denominator.
+	half := new(big.Int).Abs(rem)
+	half.Lsh(half, 1)
+	cmp := half.Cmp(r.Denom())
+
+	negative := r.Sign() < 0
+	away := false
+	switch mode {
+	case RoundHalfEven:
+		away = cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+	case RoundHalfUp:
+		away = cmp >= 0
+	case RoundDown:
+	case RoundUp:
+		away = true
+	case RoundFloor:
+		away = negative
+	case RoundCeiling:
+		away = !negative
+	}
+
+	if away {
+		if negative {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// Add returns m + n.
+func (m Money) Add(n Money) Money {
+	return Money{micros: m.micros + n.micros}
+}
+
+// Sub returns m - n.
+func (m Money) Sub(n Money) Money {
+	return Money{micros: m.micros - n.micros}
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{micros: -m.micros}
+}
+
+// Abs returns the absolute value of m.
+func (m Money) Abs() Money {
+	if m.micros < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// Mul returns m × x rounded half to even to the nearest millionth, e.g. a
+// unit price times a quantity.
+func (m Money) Mul(x float64) Money {
+	f := new(big.Rat)
+	if f.SetFloat64(x) == nil {
+		return Money{}
+	}
+	f.Mul(f, big.NewRat(m.micros, microsPerUnit))
+	product, _ := moneyFromRat(f, RoundHalfEven)
+	return product
+}
+
+// Round rounds m to the given number of decimal places using mode.
+func (m Money) Round(places int, mode RoundingMode) Money {
+	if places >= moneyScale {
+		return m
+	}
+	if places < 0 {
+		places = 0
+	}
+	step := int64(1)
+	for i := places; i < moneyScale; i++ {
+		step *= 10
+	}
+	q := roundRat(big.NewRat(m.micros, step), mode)
+	return Money{micros: q.Int64() * step}
+}
+
+// Cmp compares m and n, returning -1, 0 or +1.
+func (m Money) Cmp(n Money) int {
+	switch {
+	case m.micros < n.micros:
+		return -1
+	case m.micros > n.micros:
+		return 1
+	}
+	return 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of m.
+func (m Money) Sign() int {
+	return m.Cmp(Money{})
+}
+
+// IsZero reports whether m is zero.
+func (m Money) IsZero() bool {
+	return m.micros == 0
+}
+
+// Float64 returns m as a float64, for display or ratios. Sums should be
+// done with Add so they stay exact.
+func (m Money) Float64() float64 {
+	return float64(m.micros) / microsPerUnit
+}
+
+// String formats m with at least two decimal places and no trailing
+// zeros beyond them, e.g. "50.00" or "43.47826".
+func (m Money) String() string {
+	s := m.StringFixed(moneyScale)
+	trimmed := strings.TrimRight(s, "0")
+	if point := strings.IndexByte(s, '.'); len(trimmed) < point+3 {
+		return s[:point+3]
+	}
+	return trimmed
+}
+
+// StringFixed formats m rounded half to even to exactly the given number
+// of decimal places.
+func (m Money) StringFixed(places int) string {
+	if places > moneyScale {
+		places = moneyScale
+	}
+	if places < 0 {
+		places = 0
+	}
+	rounded := m.Round(places, RoundHalfEven).micros
+
+	sign := ""
+	u := uint64(rounded)
+	if rounded < 0 {
+		sign = "-"
+		u = uint64(-rounded)
+	}
+	units, frac := u/microsPerUnit, u%microsPerUnit
+
+	s := sign + strconv.FormatUint(units, 10)
+	if places > 0 {
+		digits := fmt.Sprintf("%06d", frac)
+		s += "." + digits[:places]
+	}
+	return s
+}
+
+// MarshalJSON encodes m as a JSON number.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or numeric string, as read by
+// ParseMoney. Null leaves m unchanged and an empty string decodes
+// as zero.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if strings.TrimSpace(s) == "" {
+			*m = Money{}
+			return nil
+		}
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// SumMoney adds up amounts exactly.
+func SumMoney(amounts ...Money) Money {
+	total := Money{}
+	for _, a := range amounts {
+		total = total.Add(a)
+	}
+	return total
+}
+
+// Currency is the currency a store trades in.
+type Currency struct {
+	// Code is the ISO 4217 code, e.g. "NZD".
+	Code string
+	// MinorUnits is the number of decimal places amounts are settled in,
+	// e.g. 2 for cents.
+	MinorUnits int
+}
+
+// currencyMinorUnits lists the currencies whose minor units are not two
+// decimal places.
+var currencyMinorUnits = map[string]int{
+	"BHD": 3, "CLP": 0, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0,
+	"KWD": 3, "LYD": 3, "OMR": 3, "PYG": 0, "TND": 3, "UGX": 0, "VND": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// NewCurrency returns the currency for an ISO 4217 code. Currencies are
+// taken to have two minor units unless known otherwise.
+func NewCurrency(code string) Currency {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	units, ok := currencyMinorUnits[code]
+	if !ok {
+		units = 2
+	}
+	return Currency{Code: code, MinorUnits: units}
+}
+
+// Round rounds m to the currency's minor units using mode, e.g. to whole
+// cents before a payment is taken.
+func (cur Currency) Round(m Money, mode RoundingMode) Money {
+	return m.Round(cur.MinorUnits, mode)
+}
+
+// Format formats m in the currency's minor units, e.g. "43.48 NZD".
+func (cur Currency) Format(m Money) string {
+	s := m.StringFixed(cur.MinorUnits)
+	if cur.Code == "" {
+		return s
+	}
+	return s + " " + cur.Code
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,123 @@
+package vend
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	valid := map[string]string{
+		"43.4782600000": "43.47826",
+		"-5":            "-5.00",
+		"+1.5":          "1.50",
+		" 0.10 ":        "0.10",
+		"0.0000005":     "0.00",
+		"0.0000015":     "0.000002",
+		"1e3":           "1000.00",
+		"1.0e-5":        "0.00001",
+		"-2.5E+2":       "-250.00",
+		"5e-30":         "0.00",
+	}
+	for in, want := range valid {
+		m, err := ParseMoney(in)
+		if err != nil {
+			t.Errorf("ParseMoney(%q): %v", in, err)
+			continue
+		}
+		if got := m.String(); got != want {
+			t.Errorf("ParseMoney(%q) = %s, want %s", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"", "0x10", "1/2", "1.", ".5", "1e", "1e31", "1e-999999999", "1e99999999999999999999", "abc", "1,000.00", "NaN", "Inf"} {
+		if m, err := ParseMoney(in); err == nil {
+			t.Errorf("ParseMoney(%q) = %s, want an error", in, m)
+		}
+	}
+}
+
+func TestMoneyRound(t *testing.T) {
+	tests := []struct {
+		in   string
+		mode RoundingMode
+		want string
+	}{
+		{"2.5", RoundHalfEven, "2.00"},
+		{"3.5", RoundHalfEven, "4.00"},
+		{"-2.5", RoundHalfEven, "-2.00"},
+		{"2.5", RoundHalfUp, "3.00"},
+		{"-2.5", RoundHalfUp, "-3.00"},
+		{"2.7", RoundDown, "2.00"},
+		{"-2.7", RoundDown, "-2.00"},
+		{"2.1", RoundUp, "3.00"},
+		{"-2.1", RoundUp, "-3.00"},
+		{"-2.1", RoundFloor, "-3.00"},
+		{"2.1", RoundFloor, "2.00"},
+		{"2.1", RoundCeiling, "3.00"},
+		{"-2.1", RoundCeiling, "-2.00"},
+	}
+	for _, tt := range tests {
+		if got := MustParseMoney(tt.in).Round(0, tt.mode).String(); got != tt.want {
+			t.Errorf("%s.Round(0, %d) = %s, want %s", tt.in, tt.mode, got, tt.want)
+		}
+	}
+
+	if got := MustParseMoney("0.125").Round(2, RoundHalfEven).String(); got != "0.12" {
+		t.Errorf("0.125 to cents = %s, want 0.12", got)
+	}
+	if got := NewCurrency("JPY").Format(MustParseMoney("1234.5")); got != "1234 JPY" {
+		t.Errorf("JPY format = %s, want 1234 JPY", got)
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	total := Money{}
+	line := MustParseMoney("43.4782600000")
+	for i := 0; i < 10000; i++ {
+		total = total.Add(line)
+	}
+	if got := total.String(); got != "434782.60" {
+		t.Errorf("sum = %s, want 434782.60", got)
+	}
+
+	if got := line.Mul(3).String(); got != "130.43478" {
+		t.Errorf("Mul(3) = %s, want 130.43478", got)
+	}
+	if got := NewMoney(4348, -2).String(); got != "43.48" {
+		t.Errorf("NewMoney(4348, -2) = %s, want 43.48", got)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		Price    Money  `json:"price"`
+		Cost     *Money `json:"cost"`
+		Tax      Money  `json:"tax"`
+		Total    *Money `json:"total"`
+		Amount   Money  `json:"amount"`
+		Discount Money  `json:"discount"`
+	}
+	in := `{"price":43.4782600000,"cost":"10.00","tax":"","total":null,"amount":-0.000001,"discount":1.0e-5}`
+	if err := json.Unmarshal([]byte(in), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Price.String() != "43.47826" || v.Cost.String() != "10.00" || !v.Tax.IsZero() || v.Total != nil ||
+		v.Amount.String() != "-0.000001" || v.Discount.String() != "0.00001" {
+		t.Fatalf("decoded %+v", v)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"price":43.47826,"cost":10.00,"tax":0.00,"total":null,"amount":-0.000001,"discount":0.00001}`
+	if string(out) != want {
+		t.Errorf("encoded %s, want %s", out, want)
+	}
+
+	for _, bad := range []string{`{"price":1e400}`, `{"price":"0x10"}`, `{"price":"1/2"}`} {
+		if err := json.Unmarshal([]byte(bad), &v); err == nil {
+			t.Errorf("decoding %s: want an error", bad)
+		}
+	}
+}
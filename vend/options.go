@@ -0,0 +1,198 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultUserAgent is sent with every request unless overridden.
+	defaultUserAgent = "Vend CLI"
+	// defaultTimeout bounds a single HTTP round trip.
+	defaultTimeout = 60 * time.Second
+	// defaultMaxRetries is how many times a failed request is tried again.
+	defaultMaxRetries = 10
+)
+
+// config holds the optional settings applied to a Client.
+type config struct {
+	httpClient *http.Client
+	baseURL    string
+	userAgent  string
+	retry      RetryPolicy
+	onRetry    func(a Attempt, wait time.Duration)
+	limiter    *limiter
+	logger     *slog.Logger
+	dayStart   time.Duration
+}
+
+// defaultConfig is used by clients that were not built with NewClient.
+// They get their own HTTP client rather than http.DefaultClient, which
+// has no timeout and is shared with the rest of the program.
+var defaultConfig = &config{
+	httpClient: &http.Client{Timeout: defaultTimeout},
+	userAgent:  defaultUserAgent,
+	retry:      NewDefaultRetryPolicy(),
+	logger:     discardLogger,
+}
+
+// Option configures a Client created by NewClient.
+type Option func(*options)
+
+// options collects settings before the client's HTTP client is built.
+type options struct {
+	httpClient *http.Client
+	transport  http.RoundTripper
+	timeout    *time.Duration
+	baseURL    string
+	userAgent  string
+	maxRetries int
+	retry      RetryPolicy
+	onRetry    func(a Attempt, wait time.Duration)
+	rateLimit  float64
+	burst      int
+	logger     *slog.Logger
+	dayStart   time.Duration
+}
+
+// WithHTTPClient sends requests through the given client instead of one
+// created for this Client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = hc
+	}
+}
+
+// WithTransport sets the RoundTripper used to send requests, e.g. to add
+// a proxy or to point the client at a local test server.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// WithTimeout bounds each HTTP round trip. A zero duration disables the timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = &d
+	}
+}
+
+// WithBaseURL overrides the https://<prefix>.vendhq.com address requests are sent to.
+func WithBaseURL(u string) Option {
+	return func(o *options) {
+		o.baseURL = strings.TrimRight(u, "/")
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) Option {
+	return func(o *options) {
+		o.userAgent = ua
+	}
+}
+
+// WithMaxRetries sets how many times a failed request is tried again by
+// the default retry policy before giving up. Zero disables retries.
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		if n < 0 {
+			n = 0
+		}
+		o.maxRetries = n
+	}
+}
+
+// WithRetryPolicy replaces the default retry policy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = p
+	}
+}
+
+// WithRetryHook registers a function called before each retry with the
+// failed attempt and how long the client will wait before trying again.
+func WithRetryHook(fn func(a Attempt, wait time.Duration)) Option {
+	return func(o *options) {
+		o.onRetry = fn
+	}
+}
+
+// WithRateLimit throttles requests to at most rps per second with bursts of
+// up to burst requests. The limit is shared by every client for the same
+// domain prefix, so concurrent callers queue instead of being rate limited.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(o *options) {
+		o.rateLimit = rps
+		o.burst = burst
+	}
+}
+
+// WithLogger sets where request, retry and pagination events are logged.
+// Nothing is logged by default.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithBusinessDayStart makes the business days of the store's Clock begin
+// d after midnight instead of at midnight, e.g. 4*time.Hour for a store
+// that trades into the early hours.
+func WithBusinessDayStart(d time.Duration) Option {
+	return func(o *options) {
+		if d < 0 || d >= 24*time.Hour {
+			d = 0
+		}
+		o.dayStart = d
+	}
+}
+
+// build resolves the collected options into a client config for a store.
+func (o *options) build(domainPrefix string) *config {
+	hc := o.httpClient
+	if hc == nil {
+		hc = &http.Client{Timeout: defaultTimeout}
+	} else {
+		// Copy so the caller's client is left untouched.
+		copied := *hc
+		hc = &copied
+	}
+	if o.transport != nil {
+		hc.Transport = o.transport
+	}
+	if o.timeout != nil {
+		hc.Timeout = *o.timeout
+	}
+
+	retry := o.retry
+	if retry == nil {
+		p := NewDefaultRetryPolicy()
+		p.MaxAttempts = o.maxRetries + 1
+		retry = p
+	}
+
+	var l *limiter
+	if o.rateLimit > 0 {
+		l = sharedLimiter(domainPrefix, o.rateLimit, o.burst)
+	}
+
+	logger := o.logger
+	if logger == nil {
+		logger = discardLogger
+	}
+
+	return &config{
+		httpClient: hc,
+		baseURL:    o.baseURL,
+		userAgent:  o.userAgent,
+		retry:      retry,
+		onRetry:    o.onRetry,
+		limiter:    l,
+		logger:     logger,
+		dayStart:   o.dayStart,
+	}
+}
@@ -2,9 +2,9 @@
 package vend
 
 import (
-	"encoding/json"
-	"log"
-	"time"
+	"context"
+	"fmt"
+	"net/url"
 )
 
 // Vend API Docs: https://docs.vendhq.com/v0.9/reference#outlets-2
@@ -17,32 +17,21 @@ type OutletPayload struct {
 
 // Outlet is usually a physical store location.
 type Outlet struct {
-	ID        *string    `json:"id,omitempty"`
-	Name      *string    `json:"name,omitempty"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	ID        *string `json:"id,omitempty"`
+	Name      *string `json:"name,omitempty"`
+	DeletedAt *Time   `json:"deleted_at,omitempty"`
 }
 
 // Outlets gets all outlets from a store.
 func (c *Client) Outlets() ([]Outlet, map[string][]Outlet, error) {
+	return c.OutletsContext(context.Background())
+}
 
-	outlets := []Outlet{}
-	page := []Outlet{}
-
-	// v is a version that is used to get outlets by page.
-	data, v, err := c.ResourcePage(0, "GET", "outlets")
-	err = json.Unmarshal(data, &page)
+// OutletsContext is like Outlets but stops when ctx is done.
+func (c *Client) OutletsContext(ctx context.Context) ([]Outlet, map[string][]Outlet, error) {
+	outlets, err := collect[Outlet](ctx, c, "outlets", 0)
 	if err != nil {
-		log.Printf("error while unmarshalling: %s", err)
-	}
-
-	outlets = append(outlets, page...)
-
-	// Use version to paginate through all pages
-	for len(page) > 0 {
-		page = []Outlet{}
-		data, v, err = c.ResourcePage(v, "GET", "outlets")
-		err = json.Unmarshal(data, &page)
-		outlets = append(outlets, page...)
+		return nil, nil, err
 	}
 
 	outletMap := make(map[string][]Outlet)
@@ -50,5 +39,26 @@ func (c *Client) Outlets() ([]Outlet, map[string][]Outlet, error) {
 		outletMap[*outlet.ID] = append(outletMap[*outlet.ID], outlet)
 	}
 
-	return outlets, outletMap, err
+	return outlets, outletMap, nil
+}
+
+// GetOutlet gets a single outlet by ID.
+func (c *Client) GetOutlet(ctx context.Context, id string) (*Outlet, error) {
+	return requestData[Outlet](ctx, c, "GET", "outlets/"+url.PathEscape(id), nil)
+}
+
+// outletID gets the ID of the outlet with the given name.
+func (c *Client) outletID(ctx context.Context, name string) (string, error) {
+	outlets, _, err := c.OutletsContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, o := range outlets {
+		if o.Name != nil && o.ID != nil && *o.Name == name && !isSet(o.DeletedAt) {
+			return *o.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: no outlet with the name %q", ErrNotFound, name)
 }
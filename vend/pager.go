@@ -0,0 +1,271 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+)
+
+// Pager walks a 2.0 API resource page by page using its version attribute.
+//
+//	p := vend.NewPager[vend.Product](&client, "products", 0)
+//	for product, err := range p.All(ctx) {
+//		...
+//	}
+//
+// The version of the last page seen is available from Version, so a later
+// crawl can pick up where this one stopped.
+type Pager[T any] struct {
+	client   *Client
+	resource string
+	version  int64
+	done     bool
+}
+
+// NewPager returns a Pager over a 2.0 resource such as "products" or
+// "sales", starting with the objects after the given version.
+func NewPager[T any](c *Client, resource string, after int64) *Pager[T] {
+	return &Pager[T]{
+		client:   c,
+		resource: resource,
+		version:  after,
+	}
+}
+
+// Version is the highest version seen so far.
+func (p *Pager[T]) Version() int64 {
+	return p.version
+}
+
+// Done reports whether the last page has been reached.
+func (p *Pager[T]) Done() bool {
+	return p.done
+}
+
+// Next gets the next page of objects. It returns an empty page once the
+// resource has been exhausted.
+func (p *Pager[T]) Next(ctx context.Context) ([]T, error) {
+	if p.done {
+		return nil, nil
+	}
+
+	data, v, err := p.client.ResourcePageContext(ctx, p.version, "GET", p.resource)
+	if err != nil {
+		return nil, err
+	}
+
+	page := []T{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("decoding %s page after version %d: %w", p.resource, p.version, err)
+		}
+	}
+
+	// Stop on an empty page, or if the version did not move on, since
+	// asking again would return the same page forever.
+	if len(page) == 0 || v <= p.version {
+		p.done = true
+	}
+	if v > p.version {
+		p.version = v
+	}
+
+	p.client.logger().DebugContext(ctx, "page",
+		"resource", p.resource, "count", len(page), "version", p.version, "done", p.done)
+
+	return page, nil
+}
+
+// Pages iterates over each page in turn. Iteration stops after the first error.
+func (p *Pager[T]) Pages(ctx context.Context) iter.Seq2[[]T, error] {
+	return iterPages[T](ctx, p)
+}
+
+// All iterates over every object in the resource. Breaking out of the loop
+// stops any further pages being requested.
+func (p *Pager[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return iterAll[T](ctx, p)
+}
+
+// collect gathers every object of a resource after the given version.
+func collect[T any](ctx context.Context, c *Client, resource string, after int64) ([]T, error) {
+	return collectAll[T](ctx, NewPager[T](c, resource, after))
+}
+
+// pager is walked a page at a time by Pager, CursorPager and SearchPager.
+type pager[T any] interface {
+	// Next gets the next page, which is empty once there are no more.
+	Next(ctx context.Context) ([]T, error)
+	// Done reports whether the last page has been reached.
+	Done() bool
+}
+
+// iterPages iterates over each page of p in turn. Iteration stops after
+// the first error or an empty page.
+func iterPages[T any](ctx context.Context, p pager[T]) iter.Seq2[[]T, error] {
+	return func(yield func([]T, error) bool) {
+		for !p.Done() {
+			page, err := p.Next(ctx)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(page) == 0 {
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+		}
+	}
+}
+
+// iterAll iterates over every object of p. Breaking out of the loop stops
+// any further pages being requested.
+func iterAll[T any](ctx context.Context, p pager[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page, err := range iterPages(ctx, p) {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// collectAll gathers every object of p, returning those gathered so far
+// along with any error.
+func collectAll[T any](ctx context.Context, p pager[T]) ([]T, error) {
+	items := []T{}
+	for page, err := range iterPages(ctx, p) {
+		if err != nil {
+			return items, err
+		}
+		items = append(items, page...)
+	}
+	return items, nil
+}
+
+// CursorDirection is the query attribute a CursorPager pages with.
+type CursorDirection string
+
+// Directions supported by Flake ID paginated endpoints.
+const (
+	Before CursorDirection = "before"
+	After  CursorDirection = "after"
+)
+
+// CursorPager walks a 2.0 API resource that pages by Flake ID, such as
+// gift cards and store credits, rather than by version.
+//
+// Vend includes the object the cursor points at on the next page as well,
+// so objects seen on the previous page are dropped.
+type CursorPager[T any] struct {
+	client    *Client
+	resource  string
+	direction CursorDirection
+	pageSize  int
+	id        func(T) string
+	cursor    string
+	seen      map[string]bool
+	done      bool
+}
+
+// NewCursorPager returns a CursorPager over a resource such as
+// "balances/gift_cards". The id function returns an object's Flake ID.
+func NewCursorPager[T any](c *Client, resource string, direction CursorDirection, id func(T) string) *CursorPager[T] {
+	return &CursorPager[T]{
+		client:    c,
+		resource:  resource,
+		direction: direction,
+		id:        id,
+	}
+}
+
+// WithPageSize sets the number of objects asked for on each page.
+func (p *CursorPager[T]) WithPageSize(n int) *CursorPager[T] {
+	p.pageSize = n
+	return p
+}
+
+// WithCursor starts the crawl from the given Flake ID instead of the beginning.
+func (p *CursorPager[T]) WithCursor(id string) *CursorPager[T] {
+	p.cursor = id
+	return p
+}
+
+// Cursor is the Flake ID the next page will be requested from.
+func (p *CursorPager[T]) Cursor() string {
+	return p.cursor
+}
+
+// Done reports whether the last page has been reached.
+func (p *CursorPager[T]) Done() bool {
+	return p.done
+}
+
+// Next gets the next page of objects, without those already returned on the
+// previous page. It returns an empty page once the resource has been exhausted.
+func (p *CursorPager[T]) Next(ctx context.Context) ([]T, error) {
+	if p.done {
+		return nil, nil
+	}
+
+	url := p.client.urlFactoryCursor(p.resource, string(p.direction), p.cursor, p.pageSize)
+	body, _, err := p.client.MakeRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := struct {
+		Data []T `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decoding %s page %s %q: %w", p.resource, p.direction, p.cursor, err)
+	}
+
+	page := make([]T, 0, len(payload.Data))
+	seen := make(map[string]bool, len(payload.Data))
+	for _, item := range payload.Data {
+		id := p.id(item)
+		if p.seen[id] || seen[id] {
+			continue
+		}
+		seen[id] = true
+		page = append(page, item)
+	}
+
+	// Stop once a page brings nothing new, or the cursor cannot move on.
+	next := p.cursor
+	if n := len(payload.Data); n > 0 {
+		next = p.id(payload.Data[n-1])
+	}
+	if len(page) == 0 || next == "" || next == p.cursor {
+		p.done = true
+	}
+	p.cursor = next
+	p.seen = seen
+
+	p.client.logger().DebugContext(ctx, "page",
+		"resource", p.resource, "count", len(page), "cursor", p.cursor, "done", p.done)
+
+	return page, nil
+}
+
+// Pages iterates over each page in turn. Iteration stops after the first error.
+func (p *CursorPager[T]) Pages(ctx context.Context) iter.Seq2[[]T, error] {
+	return iterPages[T](ctx, p)
+}
+
+// All iterates over every object in the resource.
+func (p *CursorPager[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return iterAll[T](ctx, p)
+}
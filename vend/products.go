@@ -2,8 +2,10 @@
 package vend
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
 )
 
 // Vend API Docs: https://docs.vendhq.com/v0.9/reference#products-2
@@ -36,21 +38,21 @@ type Product struct {
 	BrandName               *string          `json:"brand_name"`
 	SupplierName            *string          `json:"supplier_name"`
 	SupplierCode            *string          `json:"supplier_code"`
-	SupplyPrice             *float64         `json:"supply_price"`
+	SupplyPrice             *Money           `json:"supply_price"`
 	AccountCodePurchase     *string          `json:"account_code_purchase"`
 	AccountCodeSales        *string          `json:"account_code_sales"`
 	Source                  *string          `json:"source"`
 	TrackInventory          bool             `json:"track_inventory"`
 	Inventory               []Inventory      `json:"inventory"`
 	PriceBookEntries        []PriceBookEntry `json:"price_book_entries"`
-	Price                   *float64         `json:"price"`
-	Tax                     *float64         `json:"tax"`
+	Price                   *Money           `json:"price"`
+	Tax                     *Money           `json:"tax"`
 	TaxID                   *string          `json:"tax_id"`
 	TaxRate                 *float64         `json:"tax_rate"`
 	TaxName                 *string          `json:"tax_name"`
 	Taxes                   []Tax            `json:"taxes"`
-	UpdatedAt               *string          `json:"updated_at"`
-	DeletedAt               *string          `json:"deleted_at"`
+	UpdatedAt               *Time            `json:"updated_at"`
+	DeletedAt               *Time            `json:"deleted_at"`
 }
 
 type ProductPayload struct {
@@ -77,17 +79,17 @@ type PriceBookEntry struct {
 	OutletID                       string  `json:"outlet_id"`
 	CustomerGroupName              string  `json:"customer_group_name"`
 	CustomerGroupID                string  `json:"customer_group_id"`
-	Price                          float64 `json:"price"`
-	LoyaltyValue                   int64   `json:"loyalty_value"`
-	Tax                            float64 `json:"tax"`
+	Price                          Money   `json:"price"`
+	LoyaltyValue                   Money   `json:"loyalty_value"`
+	Tax                            Money   `json:"tax"`
 	TaxID                          string  `json:"tax_id"`
 	TaxRate                        float64 `json:"tax_rate"`
 	TaxName                        string  `json:"tax_name"`
 	DisplayRetailPriceTaxInclusive int64   `json:"display_retail_price_tax_inclusive"`
 	MinUnits                       string  `json:"min_units"`
 	MaxUnits                       string  `json:"max_units"`
-	ValidFrom                      string  `json:"valid_from"`
-	ValidTo                        string  `json:"valid_to"`
+	ValidFrom                      Time    `json:"valid_from"`
+	ValidTo                        Time    `json:"valid_to"`
 }
 
 // Tax houses product tax object
@@ -127,33 +129,17 @@ type ProductUpload struct {
 
 // Products grabs and collates all products in pages of 10,000.
 func (c *Client) Products() ([]Product, map[string]Product, error) {
+	return c.ProductsContext(context.Background())
+}
 
-	productMap := make(map[string]Product)
-	products := []Product{}
-	page := []Product{}
-	data := []byte{}
-	var v int64
-
-	// v is a version that is used to get products by page.
-	data, v, err := c.ResourcePage(0, "GET", "products")
-	err = json.Unmarshal(data, &page)
+// ProductsContext is like Products but stops when ctx is done.
+func (c *Client) ProductsContext(ctx context.Context) ([]Product, map[string]Product, error) {
+	products, err := collect[Product](ctx, c, "products", 0)
 	if err != nil {
-		log.Printf("error while unmarshalling: %s", err)
-	}
-
-	products = append(products, page...)
-
-	// Use version to paginate through all pages
-	for len(page) > 0 {
-		page = []Product{}
-		data, v, err = c.ResourcePage(v, "GET", "products")
-		err = json.Unmarshal(data, &page)
-		products = append(products, page...)
+		return nil, nil, err
 	}
 
-	productMap = buildProductMap(products)
-
-	return products, productMap, err
+	return products, buildProductMap(products), nil
 }
 
 func buildProductMap(products []Product) map[string]Product {
@@ -165,3 +151,157 @@ func buildProductMap(products []Product) map[string]Product {
 
 	return productMap
 }
+
+// ProductRequest holds the fields sent when creating or updating a product.
+// Only fields that are set are sent, so a pointer to a zero value clears a
+// field while a nil pointer leaves it untouched.
+type ProductRequest struct {
+	Name                *string             `json:"name,omitempty"`
+	Handle              *string             `json:"handle,omitempty"`
+	SKU                 *string             `json:"sku,omitempty"`
+	Description         *string             `json:"description,omitempty"`
+	Active              *bool               `json:"active,omitempty"`
+	BrandID             *string             `json:"brand_id,omitempty"`
+	SupplierID          *string             `json:"supplier_id,omitempty"`
+	SupplierCode        *string             `json:"supplier_code,omitempty"`
+	ProductTypeID       *string             `json:"product_type_id,omitempty"`
+	TagIDs              *[]string           `json:"tag_ids,omitempty"`
+	PriceExcludingTax   *Money              `json:"price_excluding_tax,omitempty"`
+	SupplyPrice         *Money              `json:"supply_price,omitempty"`
+	TaxID               *string             `json:"tax_id,omitempty"`
+	AccountCodeSales    *string             `json:"account_code,omitempty"`
+	AccountCodePurchase *string             `json:"account_code_purchase,omitempty"`
+	TrackInventory      *bool               `json:"track_inventory,omitempty"`
+	Inventory           *[]InventoryRequest `json:"inventory,omitempty"`
+	VariantParentID     *string             `json:"variant_parent_id,omitempty"`
+	VariantOptions      *[]VariantOption    `json:"variant_options,omitempty"`
+}
+
+// InventoryRequest sets a product's stock levels at an outlet.
+type InventoryRequest struct {
+	OutletID      string   `json:"outlet_id"`
+	CurrentAmount *float64 `json:"current_amount,omitempty"`
+	ReorderPoint  *float64 `json:"reorder_point,omitempty"`
+	ReorderAmount *float64 `json:"reorder_amount,omitempty"`
+}
+
+// VariantOption is one attribute of a variant, e.g. Size: Large.
+type VariantOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CreateProduct creates a product and returns it as stored by Vend.
+func (c *Client) CreateProduct(ctx context.Context, p ProductRequest) (*Product, error) {
+	if p.Name == nil || *p.Name == "" {
+		return nil, errors.New("vend: product name is required")
+	}
+	return requestData[Product](ctx, c, "POST", "products", p)
+}
+
+// UpdateProduct changes the fields set in p on an existing product.
+func (c *Client) UpdateProduct(ctx context.Context, id string, p ProductRequest) (*Product, error) {
+	if id == "" {
+		return nil, errors.New("vend: product ID is required")
+	}
+	return requestData[Product](ctx, c, "PUT", "products/"+url.PathEscape(id), p)
+}
+
+// DeleteProduct deletes a product.
+func (c *Client) DeleteProduct(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("vend: product ID is required")
+	}
+	_, _, err := c.MakeRequestContext(ctx, "DELETE", c.urlFactoryPath("products/"+url.PathEscape(id)), nil)
+	return err
+}
+
+// CreateVariantFamily creates a parent product and one variant of it for
+// each of the given requests. Each variant needs its own VariantOptions and
+// inherits the parent's name. The parent is returned first.
+func (c *Client) CreateVariantFamily(ctx context.Context, parent ProductRequest, variants []ProductRequest) ([]Product, error) {
+	if len(variants) == 0 {
+		return nil, errors.New("vend: a variant family needs at least one variant")
+	}
+	for i, v := range variants {
+		if v.VariantOptions == nil || len(*v.VariantOptions) == 0 {
+			return nil, fmt.Errorf("vend: variant %d has no variant options", i)
+		}
+	}
+
+	created, err := c.CreateProduct(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	if created.ID == nil {
+		return nil, errors.New("vend: created parent product has no ID")
+	}
+	family := []Product{*created}
+
+	for i, v := range variants {
+		v.VariantParentID = created.ID
+		if v.Name == nil {
+			v.Name = parent.Name
+		}
+
+		variant, err := c.CreateProduct(ctx, v)
+		if err != nil {
+			return family, fmt.Errorf("creating variant %d of %s: %w", i, *created.ID, err)
+		}
+		family = append(family, *variant)
+	}
+
+	return family, nil
+}
+
+// GetProduct gets a single product by ID.
+func (c *Client) GetProduct(ctx context.Context, id string) (*Product, error) {
+	return requestData[Product](ctx, c, "GET", "products/"+url.PathEscape(id), nil)
+}
+
+// GetProductBySKU gets the product with the given SKU.
+func (c *Client) GetProductBySKU(ctx context.Context, sku string) (*Product, error) {
+	return searchOne[Product](ctx, c, "products", "sku", sku)
+}
+
+// ProductQuery filters a search for products. Zero fields are not filtered on.
+type ProductQuery struct {
+	SKU        string
+	Handle     string
+	BrandID    string
+	SupplierID string
+	Tag        string
+	// Name is matched loosely against the product name.
+	Name string
+	// Offset skips that many matching products.
+	Offset int
+	// PageSize is how many results are asked for at a time. Vend's default
+	// is used if it is zero.
+	PageSize int
+	// OrderBy is the field to sort on, e.g. "name".
+	OrderBy string
+	// OrderDirection is "asc" or "desc".
+	OrderDirection string
+}
+
+// query builds the search query.
+func (q ProductQuery) query() searchQuery {
+	return newSearchQuery("products").
+		set("sku", q.SKU).
+		set("handle", q.Handle).
+		set("brand_id", q.BrandID).
+		set("supplier_id", q.SupplierID).
+		set("tag", q.Tag).
+		set("q", q.Name).
+		order(q.OrderBy, q.OrderDirection)
+}
+
+// SearchProducts gets every product matching the query.
+func (c *Client) SearchProducts(ctx context.Context, q ProductQuery) ([]Product, error) {
+	return collectAll(ctx, c.SearchProductsPager(q))
+}
+
+// SearchProductsPager returns a pager over the products matching the query.
+func (c *Client) SearchProductsPager(q ProductQuery) *SearchPager[Product] {
+	return searchPager[Product](c, q.query(), q.Offset, q.PageSize, 0)
+}
@@ -0,0 +1,112 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket throttling requests to a single store.
+// Callers reserve a token and wait their turn, so concurrent crawls queue
+// up rather than being rejected by Vend.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+	// until holds every request back while Vend has asked us to wait.
+	until time.Time
+}
+
+// limiters holds the limiter for each domain prefix, shared by all clients.
+var limiters = struct {
+	sync.Mutex
+	m map[string]*limiter
+}{m: make(map[string]*limiter)}
+
+// sharedLimiter returns the limiter for a store, creating it if needed.
+// The most recent rate and burst given for a store apply to all its clients.
+func sharedLimiter(domainPrefix string, rate float64, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	key := strings.ToLower(domainPrefix)
+
+	limiters.Lock()
+	defer limiters.Unlock()
+
+	l, ok := limiters.m[key]
+	if !ok {
+		l = &limiter{tokens: float64(burst), last: time.Now()}
+		limiters.m[key] = l
+	}
+
+	l.mu.Lock()
+	l.rate = rate
+	l.burst = float64(burst)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.mu.Unlock()
+
+	return l
+}
+
+// wait blocks until a request may be sent or ctx is done.
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+
+	// Reserve a token now, and wait for it to be refilled if we went into debt.
+	l.tokens--
+	var d time.Duration
+	if l.tokens < 0 {
+		d = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if l.until.After(now) {
+		if pause := l.until.Sub(now); pause > d {
+			d = pause
+		}
+	}
+	l.mu.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, d); err != nil {
+		// Hand the reservation back for the callers still waiting.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// pause holds back all requests for d, e.g. after Vend rate limited us.
+func (l *limiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.until) {
+		l.until = until
+	}
+}
+
+// refill adds the tokens accrued since the last call. Must hold l.mu.
+func (l *limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	if elapsed <= 0 {
+		return
+	}
+
+	l.tokens += elapsed * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
@@ -2,9 +2,8 @@
 package vend
 
 import (
-	"encoding/json"
-	"log"
-	"time"
+	"context"
+	"net/url"
 )
 
 // Vend API Docs: https://docs.vendhq.com/v0.9/reference#registers-2
@@ -17,33 +16,22 @@ type RegisterPayload struct {
 
 // Register is a register object.
 type Register struct {
-	ID        *string    `json:"id,omitempty"`
-	Name      *string    `json:"name,omitempty"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	ID        *string `json:"id,omitempty"`
+	Name      *string `json:"name,omitempty"`
+	DeletedAt *Time   `json:"deleted_at,omitempty"`
 }
 
 // Registers gets all registers from a store.
 func (c *Client) Registers() ([]Register, error) {
+	return c.RegistersContext(context.Background())
+}
 
-	registers := []Register{}
-	page := []Register{}
-
-	// v is a version that is used to get registers by page.
-	data, v, err := c.ResourcePage(0, "GET", "registers")
-	err = json.Unmarshal(data, &page)
-	if err != nil {
-		log.Printf("error while unmarshalling: %s", err)
-	}
-
-	registers = append(registers, page...)
-
-	// Use version to paginate through all pages
-	for len(page) > 0 {
-		page = []Register{}
-		data, v, err = c.ResourcePage(v, "GET", "registers")
-		err = json.Unmarshal(data, &page)
-		registers = append(registers, page...)
-	}
+// RegistersContext is like Registers but stops when ctx is done.
+func (c *Client) RegistersContext(ctx context.Context) ([]Register, error) {
+	return collect[Register](ctx, c, "registers", 0)
+}
 
-	return registers, err
+// GetRegister gets a single register by ID.
+func (c *Client) GetRegister(ctx context.Context, id string) (*Register, error) {
+	return requestData[Register](ctx, c, "GET", "registers/"+url.PathEscape(id), nil)
 }
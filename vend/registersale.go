@@ -0,0 +1,354 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Vend API Docs: https://docs.vendhq.com/v0.9/reference#register-sales
+
+// Sale statuses.
+const (
+	SaleClosed          = "CLOSED"
+	SaleSaved           = "SAVED" // parked
+	SaleLayby           = "LAYBY"
+	SaleLaybyClosed     = "LAYBY_CLOSED"
+	SaleOnAccount       = "ONACCOUNT"
+	SaleOnAccountClosed = "ONACCOUNT_CLOSED"
+	SaleVoided          = "VOIDED"
+)
+
+// saleBalanceTolerance absorbs rounding when comparing payments to the
+// sale total, as line prices are held to five decimal places.
+var saleBalanceTolerance = NewMoney(5, -3)
+
+// ErrUnbalancedSale is returned when a sale's payments do not add up to its
+// total, without calling the API.
+var ErrUnbalancedSale = errors.New("vend: sale payments do not balance")
+
+// saleCompletions is the status each open sale moves to once it is paid off.
+var saleCompletions = map[string]string{
+	SaleSaved:     SaleClosed,
+	SaleLayby:     SaleLaybyClosed,
+	SaleOnAccount: SaleOnAccountClosed,
+}
+
+// SaleRequest is a sale posted to a register. Setting ID updates an
+// existing sale, e.g. to complete a parked sale.
+type SaleRequest struct {
+	ID            *string `json:"id,omitempty"`
+	RegisterID    string  `json:"register_id"`
+	UserID        *string `json:"user_id,omitempty"`
+	CustomerID    *string `json:"customer_id,omitempty"`
+	Status        string  `json:"status"`
+	SaleDate      *Time   `json:"sale_date,omitempty"`
+	InvoiceNumber *string `json:"invoice_number,omitempty"`
+	Note          *string `json:"note,omitempty"`
+	// ReturnFor is the ID of the sale being returned.
+	ReturnFor *string              `json:"return_for,omitempty"`
+	LineItems []SaleLineRequest    `json:"register_sale_products"`
+	Payments  []SalePaymentRequest `json:"register_sale_payments"`
+}
+
+// SaleLineRequest is a product sold on a SaleRequest. Returned products
+// have a negative quantity.
+type SaleLineRequest struct {
+	ID        *string `json:"id,omitempty"`
+	ProductID string  `json:"product_id"`
+	Quantity  float64 `json:"quantity"`
+	// Price is the unit price excluding tax, after any discount.
+	Price Money `json:"price"`
+	// Tax is the tax on one unit.
+	Tax   Money   `json:"tax"`
+	TaxID *string `json:"tax_id,omitempty"`
+	// Discount is the discount already taken off each unit's price.
+	Discount     *Money  `json:"discount,omitempty"`
+	LoyaltyValue *Money  `json:"loyalty_value,omitempty"`
+	Note         *string `json:"note,omitempty"`
+}
+
+// SalePaymentRequest is a payment on a SaleRequest. Refunds have a negative amount.
+type SalePaymentRequest struct {
+	ID                    *string `json:"id,omitempty"`
+	RetailerPaymentTypeID string  `json:"retailer_payment_type_id"`
+	PaymentDate           *Time   `json:"payment_date,omitempty"`
+	Amount                Money   `json:"amount"`
+}
+
+// Total is the amount owed for the sale including tax.
+func (r SaleRequest) Total() Money {
+	total := Money{}
+	for _, line := range r.LineItems {
+		total = total.Add(line.Price.Add(line.Tax).Mul(line.Quantity))
+	}
+	return total
+}
+
+// Paid is the sum of the sale's payments.
+func (r SaleRequest) Paid() Money {
+	paid := Money{}
+	for _, payment := range r.Payments {
+		paid = paid.Add(payment.Amount)
+	}
+	return paid
+}
+
+// Validate checks the sale can be posted. Closed sales must be paid in
+// full, while parked, layby and on-account sales may be part paid.
+func (r SaleRequest) Validate() error {
+	if r.RegisterID == "" {
+		return errors.New("vend: sale register ID is required")
+	}
+	if len(r.LineItems) == 0 {
+		return errors.New("vend: a sale needs at least one line item")
+	}
+	for i, line := range r.LineItems {
+		if line.ProductID == "" {
+			return fmt.Errorf("vend: sale line %d has no product ID", i)
+		}
+		if line.Quantity == 0 {
+			return fmt.Errorf("vend: sale line %d has no quantity", i)
+		}
+	}
+	for i, payment := range r.Payments {
+		if payment.RetailerPaymentTypeID == "" {
+			return fmt.Errorf("vend: sale payment %d has no payment type", i)
+		}
+	}
+
+	total, paid := r.Total(), r.Paid()
+	switch r.Status {
+	case SaleClosed, SaleLaybyClosed, SaleOnAccountClosed:
+		if total.Sub(paid).Abs().Cmp(saleBalanceTolerance) > 0 {
+			return fmt.Errorf("%w: total %s, paid %s", ErrUnbalancedSale, total, paid)
+		}
+	case SaleLayby, SaleOnAccount:
+		if isBlank(r.CustomerID) {
+			return fmt.Errorf("vend: a %s sale needs a customer", r.Status)
+		}
+		fallthrough
+	case SaleSaved:
+		if !partPaid(total, paid) {
+			return fmt.Errorf("%w: total %s, paid %s", ErrUnbalancedSale, total, paid)
+		}
+	case SaleVoided:
+	default:
+		return fmt.Errorf("vend: unknown sale status %q", r.Status)
+	}
+	return nil
+}
+
+// partPaid reports whether paid goes towards total without going over it.
+// Refunds on returns count towards a negative total.
+func partPaid(total, paid Money) bool {
+	if paid.Abs().Cmp(saleBalanceTolerance) <= 0 {
+		return true
+	}
+	if total.Sign() != paid.Sign() {
+		return false
+	}
+	return paid.Abs().Cmp(total.Abs().Add(saleBalanceTolerance)) <= 0
+}
+
+// RegisterSale validates and posts a sale, returning it as recorded by
+// Vend. The status defaults to closed.
+func (c *Client) RegisterSale(ctx context.Context, r SaleRequest) (*Sale, error) {
+	if r.Status == "" {
+		r.Status = SaleClosed
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	if r.Payments == nil {
+		r.Payments = []SalePaymentRequest{}
+	}
+
+	body, _, err := c.MakeRequestContext(ctx, "POST", c.baseURL()+"/api/register_sales", r)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := struct {
+		RegisterSale struct {
+			ID string `json:"id"`
+		} `json:"register_sale"`
+	}{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decoding register sale response: %w", err)
+	}
+	if payload.RegisterSale.ID == "" {
+		return nil, errors.New("vend: register sale response has no sale ID")
+	}
+
+	// The 0.9 response is shaped differently, so get the 2.0 sale instead.
+	return c.GetSale(ctx, payload.RegisterSale.ID)
+}
+
+// ParkSale posts a sale that is left open on the register to be completed
+// later with CompleteSale.
+func (c *Client) ParkSale(ctx context.Context, r SaleRequest) (*Sale, error) {
+	r.Status = SaleSaved
+	return c.RegisterSale(ctx, r)
+}
+
+// CreateLayby posts a layby sale for a customer, with any deposit paid as
+// its payments.
+func (c *Client) CreateLayby(ctx context.Context, r SaleRequest) (*Sale, error) {
+	r.Status = SaleLayby
+	return c.RegisterSale(ctx, r)
+}
+
+// CreateOnAccountSale posts a sale charged to a customer's account.
+func (c *Client) CreateOnAccountSale(ctx context.Context, r SaleRequest) (*Sale, error) {
+	r.Status = SaleOnAccount
+	return c.RegisterSale(ctx, r)
+}
+
+// CompleteSale adds payments to a parked, layby or on-account sale and
+// closes it. The sale must then be paid in full.
+func (c *Client) CompleteSale(ctx context.Context, id string, payments ...SalePaymentRequest) (*Sale, error) {
+	sale, err := c.GetSale(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := ""
+	if sale.Status != nil {
+		status = *sale.Status
+	}
+	next, ok := saleCompletions[status]
+	if !ok {
+		return nil, fmt.Errorf("vend: sale %s is %q and cannot be completed", id, status)
+	}
+
+	r := saleRequestFrom(*sale)
+	r.Status = next
+	r.Payments = append(r.Payments, payments...)
+	return c.RegisterSale(ctx, r)
+}
+
+// VoidSale voids a sale, putting its products back into stock.
+func (c *Client) VoidSale(ctx context.Context, id string) (*Sale, error) {
+	sale, err := c.GetSale(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sale.Status != nil && *sale.Status == SaleVoided {
+		return nil, fmt.Errorf("vend: sale %s is already voided", id)
+	}
+
+	r := saleRequestFrom(*sale)
+	r.Status = SaleVoided
+	return c.RegisterSale(ctx, r)
+}
+
+// ReturnSale posts a return against an earlier sale. Returned lines must
+// have a negative quantity, and no more of a product can be returned than
+// was sold. The register and customer default to those of the original sale.
+func (c *Client) ReturnSale(ctx context.Context, saleID string, r SaleRequest) (*Sale, error) {
+	original, err := c.GetSale(ctx, saleID)
+	if err != nil {
+		return nil, err
+	}
+
+	sold := map[string]float64{}
+	if original.LineItems != nil {
+		for _, line := range *original.LineItems {
+			if line.ProductID != nil && line.Quantity != nil {
+				sold[*line.ProductID] += *line.Quantity
+			}
+		}
+	}
+
+	returned := map[string]float64{}
+	for i, line := range r.LineItems {
+		if line.Quantity >= 0 {
+			return nil, fmt.Errorf("vend: return line %d must have a negative quantity", i)
+		}
+		returned[line.ProductID] -= line.Quantity
+	}
+	for productID, qty := range returned {
+		if qty > sold[productID] {
+			return nil, fmt.Errorf("vend: returning %g of product %s but sale %s sold %g", qty, productID, saleID, sold[productID])
+		}
+	}
+
+	r.ReturnFor = &saleID
+	if r.RegisterID == "" && original.RegisterID != nil {
+		r.RegisterID = *original.RegisterID
+	}
+	if r.CustomerID == nil {
+		r.CustomerID = original.CustomerID
+	}
+	return c.RegisterSale(ctx, r)
+}
+
+// saleRequestFrom rebuilds the request for an existing sale so it can be
+// posted again with a new status.
+func saleRequestFrom(s Sale) SaleRequest {
+	r := SaleRequest{
+		ID:            s.ID,
+		UserID:        s.UserID,
+		CustomerID:    s.CustomerID,
+		SaleDate:      s.SaleDate,
+		InvoiceNumber: s.InvoiceNumber,
+		Note:          s.Note,
+		ReturnFor:     s.ReturnFor,
+		LineItems:     []SaleLineRequest{},
+		Payments:      []SalePaymentRequest{},
+	}
+	if s.RegisterID != nil {
+		r.RegisterID = *s.RegisterID
+	}
+	if s.Status != nil {
+		r.Status = *s.Status
+	}
+
+	if s.LineItems != nil {
+		for _, line := range *s.LineItems {
+			item := SaleLineRequest{
+				ID:           line.ID,
+				TaxID:        line.TaxID,
+				Discount:     line.Discount,
+				LoyaltyValue: line.LoyaltyValue,
+			}
+			if line.ProductID != nil {
+				item.ProductID = *line.ProductID
+			}
+			item.Quantity = firstOf(line.Quantity)
+			item.Price = firstOf(line.Price, line.UnitPrice)
+			item.Tax = firstOf(line.Tax, line.UnitTax)
+			r.LineItems = append(r.LineItems, item)
+		}
+	}
+
+	if s.Payments != nil {
+		for _, payment := range *s.Payments {
+			p := SalePaymentRequest{
+				ID:          payment.ID,
+				PaymentDate: payment.PaymentDate,
+				Amount:      firstOf(payment.Amount),
+			}
+			if payment.RetailerPaymentTypeID != nil {
+				p.RetailerPaymentTypeID = *payment.RetailerPaymentTypeID
+			}
+			r.Payments = append(r.Payments, p)
+		}
+	}
+
+	return r
+}
+
+// firstOf returns the first of the values that is set, or the zero value.
+func firstOf[T any](vs ...*T) T {
+	for _, v := range vs {
+		if v != nil {
+			return *v
+		}
+	}
+	var zero T
+	return zero
+}
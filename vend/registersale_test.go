@@ -0,0 +1,103 @@
+package vend
+
+import (
+	"errors"
+	"testing"
+)
+
+// testSale is a sale of quantity units at 43.47826 plus 6.52174 tax, so
+// 50.00 a unit, paid with the given amounts.
+func testSale(status string, quantity float64, payments ...string) SaleRequest {
+	customer := "customer"
+	r := SaleRequest{
+		RegisterID: "register",
+		CustomerID: &customer,
+		Status:     status,
+		LineItems: []SaleLineRequest{{
+			ProductID: "product",
+			Quantity:  quantity,
+			Price:     MustParseMoney("43.47826"),
+			Tax:       MustParseMoney("6.52174"),
+		}},
+	}
+	for _, amount := range payments {
+		r.Payments = append(r.Payments, SalePaymentRequest{
+			RetailerPaymentTypeID: "cash",
+			Amount:                MustParseMoney(amount),
+		})
+	}
+	return r
+}
+
+func TestSaleRequestValidate(t *testing.T) {
+	// with returns r after applying change to it.
+	with := func(r SaleRequest, change func(r *SaleRequest)) SaleRequest {
+		change(&r)
+		return r
+	}
+	noCustomer := func(r *SaleRequest) { r.CustomerID = nil }
+
+	tests := []struct {
+		name string
+		sale SaleRequest
+		// err is nil for a valid sale, ErrUnbalancedSale for one whose
+		// payments do not balance, or errOther for any other error.
+		err error
+	}{
+		{"closed paid in full", testSale(SaleClosed, 2, "60", "40"), nil},
+		{"closed within rounding", testSale(SaleClosed, 2, "99.996"), nil},
+		{"closed underpaid", testSale(SaleClosed, 2, "99.99"), ErrUnbalancedSale},
+		{"closed overpaid", testSale(SaleClosed, 2, "100.01"), ErrUnbalancedSale},
+		{"closed unpaid", testSale(SaleClosed, 1), ErrUnbalancedSale},
+		{"layby closed paid in full", testSale(SaleLaybyClosed, 1, "20", "30"), nil},
+		{"layby closed part paid", testSale(SaleLaybyClosed, 1, "20"), ErrUnbalancedSale},
+		{"on account closed paid in full", testSale(SaleOnAccountClosed, 1, "50"), nil},
+		{"on account closed part paid", testSale(SaleOnAccountClosed, 1, "49"), ErrUnbalancedSale},
+
+		{"parked unpaid", testSale(SaleSaved, 1), nil},
+		{"parked part paid", testSale(SaleSaved, 1, "10"), nil},
+		{"parked paid in full", testSale(SaleSaved, 1, "50"), nil},
+		{"parked overpaid", testSale(SaleSaved, 1, "50.01"), ErrUnbalancedSale},
+		{"parked without a customer", with(testSale(SaleSaved, 1), noCustomer), nil},
+		{"parked refunded", testSale(SaleSaved, 1, "-10"), ErrUnbalancedSale},
+
+		{"layby deposit", testSale(SaleLayby, 1, "10"), nil},
+		{"layby overpaid", testSale(SaleLayby, 1, "60"), ErrUnbalancedSale},
+		{"layby without a customer", with(testSale(SaleLayby, 1, "10"), noCustomer), errOther},
+		{"on account unpaid", testSale(SaleOnAccount, 1), nil},
+		{"on account overpaid", testSale(SaleOnAccount, 1, "51"), ErrUnbalancedSale},
+		{"on account without a customer", with(testSale(SaleOnAccount, 1), noCustomer), errOther},
+
+		{"return refunded", testSale(SaleClosed, -1, "-50"), nil},
+		{"return refunded in parts", testSale(SaleClosed, -2, "-60", "-40"), nil},
+		{"return part refunded", testSale(SaleClosed, -1, "-20"), ErrUnbalancedSale},
+		{"return paid instead of refunded", testSale(SaleClosed, -1, "50"), ErrUnbalancedSale},
+		{"parked return part refunded", testSale(SaleSaved, -1, "-20"), nil},
+		{"parked return over refunded", testSale(SaleSaved, -1, "-60"), ErrUnbalancedSale},
+		{"parked return paid", testSale(SaleSaved, -1, "20"), ErrUnbalancedSale},
+
+		{"voided", testSale(SaleVoided, 1, "10"), nil},
+		{"unknown status", testSale("OPEN", 1, "50"), errOther},
+		{"no register", with(testSale(SaleClosed, 1, "50"), func(r *SaleRequest) { r.RegisterID = "" }), errOther},
+		{"no line items", with(testSale(SaleSaved, 1), func(r *SaleRequest) { r.LineItems = nil }), errOther},
+		{"line without a product", with(testSale(SaleClosed, 1, "50"), func(r *SaleRequest) { r.LineItems[0].ProductID = "" }), errOther},
+		{"line without a quantity", testSale(SaleSaved, 0), errOther},
+		{"payment without a type", with(testSale(SaleClosed, 1, "50"), func(r *SaleRequest) { r.Payments[0].RetailerPaymentTypeID = "" }), errOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sale.Validate()
+			switch {
+			case tt.err == nil && err != nil:
+				t.Errorf("Validate() = %v, want no error", err)
+			case tt.err == errOther && (err == nil || errors.Is(err, ErrUnbalancedSale)):
+				t.Errorf("Validate() = %v, want an error other than ErrUnbalancedSale", err)
+			case tt.err == ErrUnbalancedSale && !errors.Is(err, ErrUnbalancedSale):
+				t.Errorf("Validate() = %v, want ErrUnbalancedSale", err)
+			}
+		})
+	}
+}
+
+// errOther stands for any error other than ErrUnbalancedSale in tests.
+var errOther = errors.New("other error")
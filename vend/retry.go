@@ -0,0 +1,159 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Attempt describes the outcome of a single try at sending a request.
+type Attempt struct {
+	// Number is 1 for the first try.
+	Number  int
+	Request *http.Request
+	// Response is nil when the request could not be sent.
+	Response *http.Response
+	// Err is the transport error, or an *APIError for unsuccessful responses.
+	Err error
+	// Elapsed is the time since the first try was sent.
+	Elapsed time.Duration
+}
+
+// RetryPolicy decides whether a failed attempt is tried again.
+type RetryPolicy interface {
+	// Retry returns how long to wait before the next attempt, and false
+	// if the request should not be retried.
+	Retry(a Attempt) (time.Duration, bool)
+}
+
+// RetryPolicyFunc adapts a function to the RetryPolicy interface.
+type RetryPolicyFunc func(a Attempt) (time.Duration, bool)
+
+// Retry calls f(a).
+func (f RetryPolicyFunc) Retry(a Attempt) (time.Duration, bool) {
+	return f(a)
+}
+
+// DefaultRetryPolicy retries rate limited requests, and server or transport
+// errors on idempotent requests, with jittered exponential backoff.
+// Waits requested by Vend through the Retry-After or X-RateLimit-Reset
+// headers are honoured.
+type DefaultRetryPolicy struct {
+	// MaxAttempts caps the number of tries including the first. Zero means no cap.
+	MaxAttempts int
+	// MaxElapsed stops retrying once the next wait would pass this much
+	// time since the first try. Zero means no cap.
+	MaxElapsed time.Duration
+	// BaseDelay is the backoff before the second attempt, doubling after that.
+	BaseDelay time.Duration
+	// MaxDelay caps a single backoff.
+	MaxDelay time.Duration
+}
+
+// NewDefaultRetryPolicy returns the policy used when none is configured.
+func NewDefaultRetryPolicy() *DefaultRetryPolicy {
+	return &DefaultRetryPolicy{
+		MaxAttempts: defaultMaxRetries + 1,
+		MaxElapsed:  5 * time.Minute,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// Retry implements RetryPolicy.
+func (p *DefaultRetryPolicy) Retry(a Attempt) (time.Duration, bool) {
+	if p.MaxAttempts > 0 && a.Number >= p.MaxAttempts {
+		return 0, false
+	}
+	if errors.Is(a.Err, context.Canceled) || errors.Is(a.Err, context.DeadlineExceeded) {
+		return 0, false
+	}
+
+	wait := p.backoff(a.Number)
+
+	var apiErr *APIError
+	switch {
+	case errors.As(a.Err, &apiErr):
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			// The request was rejected before being processed, so it is
+			// always safe to send it again once the limit resets.
+			if apiErr.RetryAfter > wait {
+				wait = apiErr.RetryAfter
+			}
+		case apiErr.StatusCode >= 500 && apiErr.StatusCode != http.StatusNotImplemented:
+			if !idempotent(a.Request) {
+				return 0, false
+			}
+		default:
+			return 0, false
+		}
+	case a.Err != nil:
+		if !idempotent(a.Request) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	if p.MaxElapsed > 0 && a.Elapsed+wait > p.MaxElapsed {
+		return 0, false
+	}
+	return wait, true
+}
+
+// backoff returns a jittered delay between half and all of the exponential
+// backoff for the attempt.
+func (p *DefaultRetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// idempotent reports whether sending req twice has the same effect as once.
+func idempotent(req *http.Request) bool {
+	if req == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryAfter works out how long Vend wants us to wait from the Retry-After
+// header, falling back to the X-RateLimit-Reset header on rate limited responses.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	if d := parseRetryAfter(h.Get("Retry-After"), now); d > 0 {
+		return d
+	}
+
+	reset := strings.TrimSpace(h.Get("X-RateLimit-Reset"))
+	if reset == "" {
+		return 0
+	}
+	// The reset is either a unix timestamp or a date.
+	if secs, err := strconv.ParseInt(reset, 10, 64); err == nil {
+		if d := time.Unix(secs, 0).Sub(now); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return parseRetryAfter(reset, now)
+}
@@ -0,0 +1,191 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"time"
+)
+
+// Vend API Docs: https://docs.vendhq.com/reference#search-1
+
+// searchQuery builds the query string sent to the search endpoint.
+type searchQuery url.Values
+
+// newSearchQuery starts a search for a type of object, e.g. "products".
+func newSearchQuery(kind string) searchQuery {
+	return searchQuery{"type": {kind}}
+}
+
+// set filters on a field, unless value is empty.
+func (q searchQuery) set(field, value string) searchQuery {
+	if value != "" {
+		url.Values(q).Set(field, value)
+	}
+	return q
+}
+
+// add filters on a field matching any of the values.
+func (q searchQuery) add(field string, values ...string) searchQuery {
+	for _, v := range values {
+		if v != "" {
+			url.Values(q).Add(field, v)
+		}
+	}
+	return q
+}
+
+// time filters on a date field, unless t is zero.
+func (q searchQuery) time(field string, t time.Time) searchQuery {
+	if !t.IsZero() {
+		url.Values(q).Set(field, searchTime(t))
+	}
+	return q
+}
+
+// order sorts the results by a field in the given direction.
+func (q searchQuery) order(by, direction string) searchQuery {
+	return q.set("order_by", by).set("order_direction", direction)
+}
+
+// searchPager returns a SearchPager over the results of the query. A zero
+// maxPageSize means Vend's limit for the type is not known, so the page
+// size is sent as given, or left to Vend's default if it is not set.
+func searchPager[T any](c *Client, q searchQuery, offset, pageSize, maxPageSize int) *SearchPager[T] {
+	if pageSize < 0 {
+		pageSize = 0
+	}
+	if maxPageSize > 0 && (pageSize == 0 || pageSize > maxPageSize) {
+		pageSize = maxPageSize
+	}
+	return newSearchPager[T](c, url.Values(q), offset, pageSize)
+}
+
+// searchPage requests a page of results from the 2.0 search endpoint.
+func (c *Client) searchPage(ctx context.Context, query url.Values) (json.RawMessage, error) {
+	body, _, err := c.MakeRequestContext(ctx, "GET", c.urlFactoryPath("search?"+query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := Payload{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("decoding %s search response: %w", query.Get("type"), err)
+	}
+
+	return response.Data, nil
+}
+
+// searchOne finds the single object of a type whose field has the given
+// value, returning ErrNotFound if there is none.
+func searchOne[T any](ctx context.Context, c *Client, kind, field, value string) (*T, error) {
+	query := newSearchQuery(kind).set(field, value).set("page_size", "1")
+
+	data, err := c.searchPage(ctx, url.Values(query))
+	if err != nil {
+		return nil, err
+	}
+
+	results := []T{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, fmt.Errorf("decoding %s search results: %w", kind, err)
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w: no %s with %s %q", ErrNotFound, kind, field, value)
+	}
+
+	return &results[0], nil
+}
+
+// SearchPager walks the results of a search using offset paging, moving
+// on by the number of results on each page until a page comes back empty.
+type SearchPager[T any] struct {
+	client   *Client
+	query    url.Values
+	offset   int
+	pageSize int
+	done     bool
+}
+
+// newSearchPager returns a SearchPager over the results of query, starting
+// at offset and asking for pageSize results at a time, or Vend's default
+// number if pageSize is zero.
+func newSearchPager[T any](c *Client, query url.Values, offset, pageSize int) *SearchPager[T] {
+	return &SearchPager[T]{
+		client:   c,
+		query:    query,
+		offset:   offset,
+		pageSize: pageSize,
+	}
+}
+
+// Offset is the offset the next page will be requested from.
+func (p *SearchPager[T]) Offset() int {
+	return p.offset
+}
+
+// Done reports whether the last page has been reached.
+func (p *SearchPager[T]) Done() bool {
+	return p.done
+}
+
+// Next gets the next page of results. It returns an empty page once the
+// results have been exhausted.
+func (p *SearchPager[T]) Next(ctx context.Context) ([]T, error) {
+	if p.done {
+		return nil, nil
+	}
+
+	query := url.Values{}
+	for k, v := range p.query {
+		query[k] = v
+	}
+	query.Set("offset", fmt.Sprintf("%d", p.offset))
+	if p.pageSize > 0 {
+		query.Set("page_size", fmt.Sprintf("%d", p.pageSize))
+	}
+
+	data, err := p.client.searchPage(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := []T{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("decoding %s search results at offset %d: %w", query.Get("type"), p.offset, err)
+		}
+	}
+
+	// Vend may send back fewer results than were asked for, so only an
+	// empty page means the results have run out.
+	p.offset += len(page)
+	if len(page) == 0 {
+		p.done = true
+	}
+
+	p.client.logger().DebugContext(ctx, "search page",
+		"type", query.Get("type"), "count", len(page), "offset", p.offset, "done", p.done)
+
+	return page, nil
+}
+
+// Pages iterates over each page in turn. Iteration stops after the first error.
+func (p *SearchPager[T]) Pages(ctx context.Context) iter.Seq2[[]T, error] {
+	return iterPages[T](ctx, p)
+}
+
+// All iterates over every result.
+func (p *SearchPager[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return iterAll[T](ctx, p)
+}
+
+// searchTime formats a time the way the search endpoint expects it.
+func searchTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
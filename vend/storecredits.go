@@ -2,8 +2,10 @@
 package vend
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"iter"
+	"net/url"
 )
 
 // StoreCreditPayload hold Gift Card data
@@ -16,48 +18,79 @@ type StoreCredit struct {
 	ID                      *string                  `json:"id"`
 	CustomerID              *string                  `json:"customer_id"`
 	CustomerCode            *string                  `json:"customer_code"`
-	CreatedAt               *string                  `json:"created_at"`
-	Balance                 *float64                 `json:"balance"`
-	TotalIssued             *float64                 `json:"total_credit_issued"`
-	TotalRedeemed           *float64                 `json:"total_credit_redeemed"`
+	CreatedAt               *Time                    `json:"created_at"`
+	Balance                 *Money                   `json:"balance"`
+	TotalIssued             *Money                   `json:"total_credit_issued"`
+	TotalRedeemed           *Money                   `json:"total_credit_redeemed"`
 	StoreCreditTransactions []StoreCreditTransaction `json:"store_credit_transactions"`
 }
 
 // StoreCreditTransaction is a Store Credit object.
+// CustomerCode and CustomerID are not sent by Vend; they are filled in
+// from the parent account by StoreCreditTransactions.
 type StoreCreditTransaction struct {
 	ID           *string `json:"id,omitempty"`
 	CustomerCode string  `json:"-"`
 	CustomerID   string  `json:"-"`
-	Amount       float64 `json:"amount"`
+	Amount       Money   `json:"amount"`
 	Type         string  `json:"type"`
 	Notes        *string `json:"notes"`
 	UserID       *string `json:"user_id"`
 	SaleID       *string `json:"sale_id,omitempty"`
 	ClientID     *string `json:"client_id,omitempty"`
-	CreatedAt    *string `json:"created_at,omitempty"`
+	CreatedAt    *Time   `json:"created_at,omitempty"`
 }
 
 // StoreCredits gets all Store Credit data from a store.
 func (c *Client) StoreCredits() ([]StoreCredit, error) {
+	return c.StoreCreditsContext(context.Background())
+}
 
-	storecredits := []StoreCredit{}
-
-	url := fmt.Sprintf("https://%v.vendhq.com/api/2.0/store_credits?page_size=1000", c.DomainPrefix)
-	data, _, err := c.MakeRequest("GET", url, nil)
+// StoreCreditsContext is like StoreCredits but bound to ctx.
+func (c *Client) StoreCreditsContext(ctx context.Context) ([]StoreCredit, error) {
+	storecredits, err := collectAll(ctx, c.storeCreditPager())
 	if err != nil {
-		return []StoreCredit{}, fmt.Errorf("Failed to retrieve a page of data %v", err)
+		return nil, fmt.Errorf("Failed to retrieve a page of data %w", err)
 	}
 
-	payload := StoreCreditPayload{}
+	return storecredits, nil
+}
 
-	// Unmarshal payload into Store Credit object.
-	err = json.Unmarshal(data, &payload)
-	if err != nil {
-		return []StoreCredit{}, err
-	}
+// StoreCreditForCustomer gets the Store Credit account of a single customer.
+func (c *Client) StoreCreditForCustomer(ctx context.Context, customerID string) (*StoreCredit, error) {
+	return requestData[StoreCredit](ctx, c, "GET", "store_credits/"+url.PathEscape(customerID), nil)
+}
 
-	// Append page to list.
-	storecredits = append(storecredits, payload.Data...)
+// StoreCreditTransactions iterates over every Store Credit transaction in the
+// store, with the customer each belongs to filled in.
+func (c *Client) StoreCreditTransactions(ctx context.Context) iter.Seq2[StoreCreditTransaction, error] {
+	return func(yield func(StoreCreditTransaction, error) bool) {
+		for credit, err := range c.storeCreditPager().All(ctx) {
+			if err != nil {
+				yield(StoreCreditTransaction{}, err)
+				return
+			}
+			for _, t := range credit.StoreCreditTransactions {
+				if credit.CustomerID != nil {
+					t.CustomerID = *credit.CustomerID
+				}
+				if credit.CustomerCode != nil {
+					t.CustomerCode = *credit.CustomerCode
+				}
+				if !yield(t, nil) {
+					return
+				}
+			}
+		}
+	}
+}
 
-	return storecredits, err
+// storeCreditPager pages through Store Credit accounts by Flake ID.
+func (c *Client) storeCreditPager() *CursorPager[StoreCredit] {
+	return NewCursorPager(c, "store_credits", After, func(s StoreCredit) string {
+		if s.ID == nil {
+			return ""
+		}
+		return *s.ID
+	}).WithPageSize(1000)
 }
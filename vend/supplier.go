@@ -2,8 +2,10 @@
 package vend
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 )
 
 // Vend API Docs: https://docs.vendhq.com/v0.9/reference#suppliers-1
@@ -51,15 +53,23 @@ type Contact struct {
 
 // Suppliers gets all Suppliers from a store.
 func (c *Client) Suppliers() ([]SupplierBase, error) {
+	return c.SuppliersContext(context.Background())
+}
+
+// SuppliersContext is like Suppliers but stops when ctx is done.
+func (c *Client) SuppliersContext(ctx context.Context) ([]SupplierBase, error) {
 
 	suppliers := []SupplierBase{}
 
-	data, more, nextPage, err := c.Pages("api/supplier", 0)
+	data, more, nextPage, err := c.PagesContext(ctx, "api/supplier", 0)
+	if err != nil {
+		return nil, err
+	}
 	suppliers = append(suppliers, data...)
 
 	for more {
 		// Continue grabbing pages until we receive an empty one.
-		data, more, nextPage, err = c.Pages("api/supplier", nextPage)
+		data, more, nextPage, err = c.PagesContext(ctx, "api/supplier", nextPage)
 		if err != nil {
 			return nil, err
 		}
@@ -70,26 +80,32 @@ func (c *Client) Suppliers() ([]SupplierBase, error) {
 	return suppliers, err
 }
 
+// Pages gets a single page of suppliers from a paginated 0.9 API resource.
 func (c Client) Pages(resource string, page int64) ([]SupplierBase, bool, int64, error) {
+	return c.PagesContext(context.Background(), resource, page)
+}
+
+// PagesContext is like Pages but bound to ctx.
+func (c Client) PagesContext(ctx context.Context, resource string, page int64) ([]SupplierBase, bool, int64, error) {
 
 	url := ""
 
 	if page > 0 {
-		url = fmt.Sprintf("https://%s.vendhq.com/%s?page_size=200&page=%v", c.DomainPrefix, resource, page)
+		url = fmt.Sprintf("%s/%s?page_size=200&page=%v", c.baseURL(), resource, page)
 	} else {
-		url = fmt.Sprintf("https://%s.vendhq.com/%s?page_size=200", c.DomainPrefix, resource)
+		url = fmt.Sprintf("%s/%s?page_size=200", c.baseURL(), resource)
 	}
 
-	body, _, err := c.MakeRequest("GET", url, nil)
+	body, _, err := c.MakeRequestContext(ctx, "GET", url, nil)
 	if err != nil {
-		fmt.Printf("Error getting resource: %s", err)
+		return nil, false, 0, err
 	}
 
 	// Decode the raw JSON.
 	response := SupplierCollectionResponse{}
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		fmt.Printf("\nError unmarshalling payload: %s", err)
+		c.logger().ErrorContext(ctx, "unmarshalling supplier payload", "page", page, "error", err)
 		return nil, false, 0, err
 	}
 
@@ -104,3 +120,8 @@ func (c Client) Pages(resource string, page int64) ([]SupplierBase, bool, int64,
 
 	return data, more, pg, err
 }
+
+// GetSupplier gets a single supplier by ID.
+func (c *Client) GetSupplier(ctx context.Context, id string) (*SupplierBase, error) {
+	return requestData[SupplierBase](ctx, c, "GET", "suppliers/"+url.PathEscape(id), nil)
+}
@@ -0,0 +1,102 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeLayouts are the timestamp formats Vend sends, tried in order.
+// Timestamps without an offset are in UTC. Fractional seconds are accepted
+// by every layout.
+var timeLayouts = []string{
+	time.RFC3339,          // 2.0 API, e.g. 2017-02-07T01:37:58+00:00
+	"2006-01-02T15:04:05", // 2.0 API without an offset
+	"2006-01-02 15:04:05", // 0.9 API
+	"2006-01-02",          // dates such as price book validity
+}
+
+// zeroTimestamps are sent by some endpoints in place of null.
+var zeroTimestamps = map[string]bool{
+	"":                    true,
+	"0000-00-00":          true,
+	"0000-00-00 00:00:00": true,
+}
+
+// Time is a timestamp from Vend. It decodes every format Vend uses, and
+// null or empty timestamps decode as the zero time, so check IsZero before
+// using it. Times encode in RFC 3339 format in UTC.
+type Time struct {
+	time.Time
+}
+
+// ParseTime parses a Vend timestamp in any of the formats Vend sends.
+// Empty timestamps give the zero time.
+func ParseTime(s string) (Time, error) {
+	s = strings.TrimSpace(s)
+	if zeroTimestamps[s] {
+		return Time{}, nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Time{t}, nil
+		}
+	}
+	return Time{}, fmt.Errorf("vend: parsing timestamp %q: unknown format", s)
+}
+
+// MarshalJSON encodes t in RFC 3339 format in UTC, or as null if it is zero.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.UTC().Format(time.RFC3339))
+}
+
+// UnmarshalJSON decodes any Vend timestamp, or null.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*t = Time{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("vend: timestamp must be a string: %w", err)
+	}
+	parsed, err := ParseTime(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// isSet reports whether an optional timestamp holds a time, e.g. whether
+// an object has been deleted.
+func isSet(t *Time) bool {
+	return t != nil && !t.IsZero()
+}
+
+// locations caches loaded timezones, as loading reads the zoneinfo database.
+var locations sync.Map
+
+// loadLocation loads a timezone by name such as "Pacific/Auckland", caching
+// it for later calls. An empty name is UTC.
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("loading timezone %q as location: %w", name, err)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
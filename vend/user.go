@@ -2,8 +2,8 @@
 package vend
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"net/url"
 )
 
 // Vend API Docs: https://docs.vendhq.com/v0.9/reference#users-2
@@ -22,32 +22,21 @@ type User struct {
 	AccountType      *string `json:"account_type,omitempty"`
 	Email            *string `json:"email,omitempty"`
 	RestrictedOutlet *string `json:"restricted_outlet_id,omitempty"`
-	CreatedAt        *string `json:"created_at,omitempty"`
-	DeletedAt        *string `json:"deleted_at,omitempty"`
+	CreatedAt        *Time   `json:"created_at,omitempty"`
+	DeletedAt        *Time   `json:"deleted_at,omitempty"`
 }
 
 // Users gets all users from a store.
 func (c *Client) Users() ([]User, error) {
+	return c.UsersContext(context.Background())
+}
 
-	users := []User{}
-	page := []User{}
-
-	// v is a version that is used to get registers by page.
-	data, v, err := c.ResourcePage(0, "GET", "users")
-	err = json.Unmarshal(data, &page)
-	if err != nil {
-		log.Printf("error while unmarshalling: %s", err)
-	}
-
-	users = append(users, page...)
-
-	// Use version to paginate through all pages
-	for len(page) > 0 {
-		page = []User{}
-		data, v, err = c.ResourcePage(v, "GET", "users")
-		err = json.Unmarshal(data, &page)
-		users = append(users, page...)
-	}
+// UsersContext is like Users but stops when ctx is done.
+func (c *Client) UsersContext(ctx context.Context) ([]User, error) {
+	return collect[User](ctx, c, "users", 0)
+}
 
-	return users, err
+// GetUser gets a single user by ID.
+func (c *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	return requestData[User](ctx, c, "GET", "users/"+url.PathEscape(id), nil)
 }
@@ -3,14 +3,13 @@ package vend
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"math"
 	"net/http"
 	"net/url"
-	"os"
 	"time"
 )
 
@@ -19,106 +18,213 @@ type Client struct {
 	Token        string
 	DomainPrefix string
 	TimeZone     string
+
+	cfg *config
 }
 
 // NewClient is called to pass authentication details to the manager.
-func NewClient(Token, DomainPrefix, tz string) Client {
-	return Client{Token, DomainPrefix, tz}
+// Options can be given to change how requests are sent.
+func NewClient(Token, DomainPrefix, tz string, opts ...Option) Client {
+	o := &options{
+		userAgent:  defaultUserAgent,
+		maxRetries: defaultMaxRetries,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return Client{
+		Token:        Token,
+		DomainPrefix: DomainPrefix,
+		TimeZone:     tz,
+		cfg:          o.build(DomainPrefix),
+	}
+}
+
+// config returns the client's settings, falling back to the defaults for
+// clients that were built without NewClient.
+func (c *Client) config() *config {
+	if c.cfg == nil {
+		return defaultConfig
+	}
+	return c.cfg
+}
+
+// baseURL is the address of the store, without a trailing slash.
+func (c *Client) baseURL() string {
+	if u := c.config().baseURL; u != "" {
+		return u
+	}
+	return fmt.Sprintf("https://%s.vendhq.com", c.DomainPrefix)
 }
 
 // NewRequest performs a request to a Vend API endpoint.
 func (c *Client) NewRequest(method, url string, body interface{}) (*http.Request, error) {
+	return c.NewRequestWithContext(context.Background(), method, url, body)
+}
+
+// NewRequestWithContext is like NewRequest but the request is bound to ctx.
+func (c *Client) NewRequestWithContext(ctx context.Context, method, url string, body interface{}) (*http.Request, error) {
 
 	// Convert body into JSON
 	b, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
 	}
-	bb := bytes.NewReader(b)
 
-	req, err := http.NewRequest(method, url, bb)
+	return c.newRequest(ctx, method, url, "application/json", b)
+}
+
+// newRequest builds an authenticated request with an already encoded body.
+func (c *Client) newRequest(ctx context.Context, method, url, contentType string, body []byte) (*http.Request, error) {
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
 	if err != nil {
-		fmt.Printf("\nError creating http request: %s", err)
+		c.logger().ErrorContext(ctx, "creating request", "method", method, "error", err)
 		return nil, err
 	}
 
 	// Request Headers
-	req.Header.Set("User-Agent", "Vend CLI")
-	req.Header.Add("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.config().userAgent)
+	req.Header.Add("Content-Type", contentType)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
 
 	return req, nil
 }
 
-// Do request
+// Do sends the request, retrying failed attempts as the client's
+// RetryPolicy allows. Waits between attempts end early if the request's
+// context is cancelled. An unsuccessful status code is returned as an *APIError.
 func (c *Client) Do(req *http.Request) ([]byte, int, error) {
 
-	client := http.DefaultClient
-	var attempt int
-	var resp *http.Response
-	var err error
-	for {
-		resp, err = client.Do(req)
-		if err != nil {
-			fmt.Printf("\nError performing request: %s", err)
-			// Delays between attempts will be exponentially longer each time.
-			attempt++
-			delay := BackoffDuration(attempt)
-			time.Sleep(delay)
-		} else {
-			break
+	cfg := c.config()
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		body, resp, err := c.send(req)
+		if err == nil {
+			return body, resp.StatusCode, nil
+		}
+
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
+		}
+
+		a := Attempt{
+			Number:   attempt,
+			Request:  req,
+			Response: resp,
+			Err:      err,
+			Elapsed:  time.Since(start),
+		}
+		wait, ok := cfg.retry.Retry(a)
+		if !ok || req.Context().Err() != nil {
+			return nil, statusCode, err
+		}
+		c.logger().WarnContext(req.Context(), "retrying request",
+			"method", req.Method,
+			"path", req.URL.Path,
+			"attempt", attempt,
+			"status", statusCode,
+			"wait", wait,
+			"error", err,
+		)
+		if cfg.onRetry != nil {
+			cfg.onRetry(a, wait)
+		}
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, statusCode, err
+		}
+
+		// The body has been consumed by the failed attempt, so rewind it.
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, statusCode, err
+			}
 		}
 	}
+}
 
-	defer resp.Body.Close()
-	if ResponseCheck(resp.StatusCode) {
-		responseBody, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			fmt.Printf("\nError while reading response body: %s\n", err)
-			return nil, resp.StatusCode, err
+// send performs a single attempt at a request and reads the whole response.
+func (c *Client) send(req *http.Request) ([]byte, *http.Response, error) {
+	cfg := c.config()
+	if cfg.limiter != nil {
+		if err := cfg.limiter.wait(req.Context()); err != nil {
+			return nil, nil, err
 		}
-		return responseBody, resp.StatusCode, err
 	}
 
-	return nil, resp.StatusCode, err
-}
+	logger := c.logger()
+	start := time.Now()
+	resp, err := cfg.httpClient.Do(req)
+	if err != nil {
+		logger.DebugContext(req.Context(), "performing request",
+			"method", req.Method, "path", req.URL.Path, "error", err)
+		return nil, nil, err
+	}
 
-func (c Client) MakeRequest(method, url string, body interface{}) ([]byte, int, error) {
-	req, err := c.NewRequest(method, url, body)
+	defer resp.Body.Close()
+	responseBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, 0, err
+		logger.DebugContext(req.Context(), "reading response body",
+			"method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "error", err)
+		return nil, resp, err
 	}
 
-	var res []byte
-	statusCode := 300
-	try := 1
-
-	// Inconsistant responses from data 2013/2014 retry if you we receieve anything less that 300 response
-	for statusCode > 299 {
-		req, err = c.NewRequest(method, url, body)
-		res, statusCode, err = c.Do(req)
-		try++
-		time.Sleep(1 * time.Second)
-		if try > 10 {
-			break
+	logger.DebugContext(req.Context(), "request",
+		"method", req.Method,
+		"path", req.URL.Path,
+		"status", resp.StatusCode,
+		"duration", time.Since(start),
+		"bytes", len(responseBody),
+	)
+
+	if !ResponseCheck(resp.StatusCode) {
+		apiErr := newAPIError(req, resp, responseBody)
+		// Hold back the other callers for this store until Vend lets us in again.
+		if cfg.limiter != nil && apiErr.StatusCode == http.StatusTooManyRequests {
+			cfg.limiter.pause(apiErr.RetryAfter)
 		}
+		return nil, resp, apiErr
 	}
+
+	return responseBody, resp, nil
+}
+
+// MakeRequest builds and sends a request, retrying failed attempts.
+func (c Client) MakeRequest(method, url string, body interface{}) ([]byte, int, error) {
+	return c.MakeRequestContext(context.Background(), method, url, body)
+}
+
+// MakeRequestContext is like MakeRequest but stops retrying once ctx is done.
+// Server errors on older (2013/2014) data are inconsistent, so these are
+// retried by the default policy.
+func (c Client) MakeRequestContext(ctx context.Context, method, url string, body interface{}) ([]byte, int, error) {
+	req, err := c.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return nil, statusCode, err
+		return nil, 0, err
 	}
 
-	return res, statusCode, nil
+	return c.Do(req)
 }
 
 // ResourcePage gets a single page of data from a 2.0 API resource using a version attribute.
 func (c *Client) ResourcePage(version int64, method, resource string) ([]byte, int64, error) {
+	return c.ResourcePageContext(context.Background(), version, method, resource)
+}
+
+// ResourcePageContext is like ResourcePage but bound to ctx.
+func (c *Client) ResourcePageContext(ctx context.Context, version int64, method, resource string) ([]byte, int64, error) {
 
 	url := c.urlFactory(version, "", resource)
-	body, _, err := c.MakeRequest(method, url, nil)
+	body, _, err := c.MakeRequestContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
 	response := Payload{}
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		fmt.Printf("Error unmarshalling payload: %s", err)
+		c.logger().ErrorContext(ctx, "unmarshalling payload", "resource", resource, "error", err)
 		return nil, 0, err
 	}
 
@@ -129,51 +235,50 @@ func (c *Client) ResourcePage(version int64, method, resource string) ([]byte, i
 }
 
 // ResourcePageFlake gets a single page of data from a 2.0 API resource using a Flake ID attribute.
+// The returned ID is that of the last object on the page, or empty if the page is empty.
+// Vend repeats that object on the following page; use CursorPager to have it removed.
 func (c *Client) ResourcePageFlake(id, method, resource string) ([]byte, string, error) {
+	return c.ResourcePageFlakeContext(context.Background(), id, method, resource)
+}
+
+// ResourcePageFlakeContext is like ResourcePageFlake but bound to ctx.
+func (c *Client) ResourcePageFlakeContext(ctx context.Context, id, method, resource string) ([]byte, string, error) {
 
 	// Build the URL for the resource page.
 	url := c.urlFactoryFlake(id, resource)
-	body, _, err := c.MakeRequest(method, url, nil)
+	body, _, err := c.MakeRequestContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
 	payload := map[string][]interface{}{}
 	err = json.Unmarshal(body, &payload)
 	if err != nil {
-		fmt.Printf("\nError unmarshalling payload: %s", err)
+		c.logger().ErrorContext(ctx, "unmarshalling payload", "resource", resource, "error", err)
 		return nil, "", err
 	}
 
 	items := payload["data"]
 
 	// Retrieve the last ID from the payload to be used to request subsequent page
-	// **TODO** Last ID will be stripped as its included in the previous payload, need a better way to handle this
-	i := items[(len(items) - 1)]
-	m := i.(map[string]interface{})
-	lastID := m["id"].(string)
+	lastID := ""
+	if len(items) > 0 {
+		if m, ok := items[len(items)-1].(map[string]interface{}); ok {
+			lastID, _ = m["id"].(string)
+		}
+	}
 
-	return body, lastID, err
+	return body, lastID, nil
 }
 
-// ResponseCheck checks the HTTP status codes of responses.
+// ResponseCheck reports whether a status code is successful. Unsuccessful
+// responses are returned from Do as an *APIError.
 func ResponseCheck(statusCode int) bool {
-	switch {
-	case statusCode < 300:
-		return true
-	case statusCode == 401:
-		fmt.Printf("\nAccess denied - check API Token. Status: %d", statusCode)
-		os.Exit(0)
-	case statusCode == 404:
-		fmt.Printf("\nURL not found - Status: %d", statusCode)
-		os.Exit(0)
-	case statusCode == 429:
-		fmt.Printf("\nRate limited by the Vend API :S Status: %d", statusCode)
-	case statusCode >= 500:
-		fmt.Printf("\nServer error. Status: %d", statusCode)
-	default:
-		fmt.Printf("\nGot an unknown status code - Google it. Status: %d", statusCode)
-	}
-	return false
+	return statusCode < 300
 }
 
-// BackoffDuration ...
+// BackoffDuration is the delay between attempts used by earlier versions of the client.
+//
+// Deprecated: retries are now governed by the client's RetryPolicy.
 func BackoffDuration(attempt int) time.Duration {
 	if attempt <= 0 {
 		attempt = 1
@@ -182,6 +287,19 @@ func BackoffDuration(attempt int) time.Duration {
 	return time.Second * time.Duration(seconds)
 }
 
+// sleep pauses for d, returning early with the context's error if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
 // urlFactory creates a Vend API 2.0 URL based on a resource.
 func (c *Client) urlFactory(version int64, objectID, resource string) string {
 	// Page size is capped at ten thousand for all endpoints except sales which it is capped at five hundred.
@@ -191,7 +309,7 @@ func (c *Client) urlFactory(version int64, objectID, resource string) string {
 	)
 
 	// Using 2.x Endpoint.
-	address := fmt.Sprintf("https://%s.vendhq.com/api/2.0/", c.DomainPrefix)
+	address := c.baseURL() + "/api/2.0/"
 	query := url.Values{}
 	query.Add("after", fmt.Sprintf("%d", version))
 
@@ -212,13 +330,25 @@ func (c *Client) urlFactoryFlake(id, resource string) string {
 		deleted  = true
 	)
 
+	// Iterate through pages using the ?before= FLAKE ID attribute.
+	return c.urlFactoryCursor(resource, string(Before), id, 0)
+}
+
+// urlFactoryCursor creates a Vend API 2.0 URL for a page of a resource
+// after or before the given Flake ID. A zero pageSize leaves it to Vend.
+func (c *Client) urlFactoryCursor(resource, param, id string, pageSize int) string {
+
 	// Using 2.x Endpoint.
-	address := fmt.Sprintf("https://%s.vendhq.com/api/2.0/%s", c.DomainPrefix, resource)
+	address := fmt.Sprintf("%s/api/2.0/%s", c.baseURL(), resource)
 
-	// Iterate through pages using the ?before= FLAKE ID attribute.
+	query := url.Values{}
 	if id != "" {
-		query := url.Values{}
-		query.Add("before", fmt.Sprintf("%s", id))
+		query.Add(param, id)
+	}
+	if pageSize > 0 {
+		query.Add("page_size", fmt.Sprintf("%d", pageSize))
+	}
+	if len(query) > 0 {
 		address += fmt.Sprintf("?%s", query.Encode())
 	}
 
@@ -227,32 +357,72 @@ func (c *Client) urlFactoryFlake(id, resource string) string {
 
 // ImageUploadURLFactory creates the Vend URL for uploading an image.
 func (c Client) ImageUploadURLFactory(productID string) string {
-	url := fmt.Sprintf("https://%s.vendhq.com/api/2.0/products/%s/actions/image_upload",
-		c.DomainPrefix, productID)
+	url := fmt.Sprintf("%s/api/2.0/products/%s/actions/image_upload",
+		c.baseURL(), productID)
 	return url
 }
 
 // ParseVendDT converts the default Vend timestamp string into a go Time.time value.
+// The zero time is returned if the timestamp or timezone cannot be parsed;
+// use ParseVendTime to find out why.
 func ParseVendDT(dt, tz string) time.Time {
+	t, err := ParseVendTime(dt, tz)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// ParseVendTime converts a Vend timestamp string in any of the formats
+// accepted by ParseTime into a time in the store's timezone.
+func ParseVendTime(dt, tz string) (time.Time, error) {
 
 	// Load store's timezone as location.
-	loc, err := time.LoadLocation(tz)
+	loc, err := loadLocation(tz)
 	if err != nil {
-		fmt.Printf("Error loading timezone as location: %s", err)
+		return time.Time{}, err
 	}
 
-	// Default Vend timedate layout.
-	const longForm = "2006-01-02T15:04:05Z07:00"
-	t, err := time.Parse(longForm, dt)
+	t, err := ParseTime(dt)
 	if err != nil {
-		log.Fatalf("Error parsing time into deafult timestamp: %s", err)
+		return time.Time{}, err
 	}
 
 	// Time in retailer's timezone.
-	dtWithTimezone := t.In(loc)
+	return t.In(loc), nil
+}
+
+// String returns a pointer to v, for setting optional request fields.
+func String(v string) *string { return &v }
 
-	return dtWithTimezone
+// Bool returns a pointer to v, for setting optional request fields.
+func Bool(v bool) *bool { return &v }
+
+// Int64 returns a pointer to v, for setting optional request fields.
+func Int64(v int64) *int64 { return &v }
+
+// Float64 returns a pointer to v, for setting optional request fields.
+func Float64(v float64) *float64 { return &v }
+
+// urlFactoryPath creates a Vend API 2.0 URL for a path such as "products/<id>".
+func (c *Client) urlFactoryPath(path string) string {
+	return fmt.Sprintf("%s/api/2.0/%s", c.baseURL(), path)
+}
+
+// requestData sends a request to a Vend API 2.0 path and decodes the object
+// held in the data attribute of the response.
+func requestData[T any](ctx context.Context, c *Client, method, path string, body interface{}) (*T, error) {
+	data, _, err := c.MakeRequestContext(ctx, method, c.urlFactoryPath(path), body)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := struct {
+		Data T `json:"data"`
+	}{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("decoding %s %s response: %w", method, path, err)
+	}
 
-	// Time string with timezone removed.
-	// timeStr := timeLoc.String()[0:19]
+	return &payload.Data, nil
 }
@@ -0,0 +1,121 @@
+// Package vend handles interactions with the Vend API.
+package vend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// versionAtMargin is taken off the time given to VersionAt, as versions are
+// only roughly in the order objects were changed.
+const versionAtMargin = time.Hour
+
+// versionProbe is the part of a versioned object VersionAt looks at.
+type versionProbe struct {
+	Version   *int64 `json:"version"`
+	UpdatedAt *Time  `json:"updated_at"`
+	CreatedAt *Time  `json:"created_at"`
+}
+
+// VersionAt finds a version of a 2.0 resource such as "sales" to crawl
+// from so that every object changed at or after t is included.
+//
+// Versions go up each time an object changes, so the objects changed
+// before t come first. VersionAt binary searches for the first version
+// changed at or after t, asking for a single object at a time. Backdated
+// and offline sales are still found because they were changed on the
+// server no earlier than their sale date.
+func (c *Client) VersionAt(ctx context.Context, resource string, t time.Time) (int64, error) {
+	t = t.Add(-versionAtMargin)
+
+	// before reports whether the object after v was changed before t, and
+	// returns its version.
+	before := func(v int64) (bool, int64, error) {
+		next, changed, err := c.probeVersion(ctx, resource, v)
+		if err != nil || next == 0 {
+			return false, 0, err
+		}
+		return changed.Before(t), next, nil
+	}
+
+	ok, next, err := before(0)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	// The answer is at least low. Gallop forward to find a version high
+	// enough that the object after it was changed at or after t.
+	low, high := next, int64(0)
+	for step := int64(1 << 20); ; step *= 2 {
+		if step > 1<<61 {
+			return low, nil
+		}
+		candidate := low + step
+		ok, next, err := before(candidate)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			high = candidate
+			break
+		}
+		low = max(candidate+1, next)
+	}
+
+	for low < high {
+		mid := low + (high-low)/2
+		ok, next, err := before(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			low = max(mid+1, next)
+		} else {
+			high = mid
+		}
+	}
+
+	c.logger().DebugContext(ctx, "version at", "resource", resource, "time", t, "version", low)
+
+	return low, nil
+}
+
+// probeVersion gets the version of the object after v and when it was last
+// changed. A zero version means there are no objects after v.
+func (c *Client) probeVersion(ctx context.Context, resource string, v int64) (int64, time.Time, error) {
+	query := url.Values{}
+	query.Set("after", fmt.Sprintf("%d", v))
+	query.Set("page_size", "1")
+
+	body, _, err := c.MakeRequestContext(ctx, "GET", c.urlFactoryPath(resource+"?"+query.Encode()), nil)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	payload := struct {
+		Data []versionProbe `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return 0, time.Time{}, fmt.Errorf("decoding %s after version %d: %w", resource, v, err)
+	}
+	if len(payload.Data) == 0 {
+		return 0, time.Time{}, nil
+	}
+
+	obj := payload.Data[0]
+	if obj.Version == nil {
+		return 0, time.Time{}, fmt.Errorf("vend: %s has no version", resource)
+	}
+	changed := obj.UpdatedAt
+	if !isSet(changed) {
+		changed = obj.CreatedAt
+	}
+	if !isSet(changed) {
+		return 0, time.Time{}, fmt.Errorf("vend: %s has no updated_at or created_at to search by", resource)
+	}
+
+	return *obj.Version, changed.Time, nil
+}
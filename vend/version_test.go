@@ -0,0 +1,112 @@
+package vend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// versionedObject is an object of the fake resource served to VersionAt.
+type versionedObject struct {
+	Version   int64 `json:"version"`
+	UpdatedAt Time  `json:"updated_at"`
+}
+
+// versionedServer serves objects the way a 2.0 resource does when asked
+// for the page after a version.
+func versionedServer(t *testing.T, objects []versionedObject) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		after, err := strconv.ParseInt(r.URL.Query().Get("after"), 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		size, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+
+		page := []versionedObject{}
+		for _, obj := range objects {
+			if obj.Version > after && (size == 0 || len(page) < size) {
+				page = append(page, obj)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": page})
+	}))
+}
+
+func TestVersionAt(t *testing.T) {
+	// One object changed a day. The first half have versions with uneven
+	// and growing gaps, and the second half consecutive versions, so the
+	// search has to land on an exact version.
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	objects := []versionedObject{}
+	for i := int64(1); i <= 60; i++ {
+		v := i*i*7_000_003 + i%7
+		if i > 30 {
+			v = objects[29].Version + i - 30
+		}
+		objects = append(objects, versionedObject{
+			Version:   v,
+			UpdatedAt: Time{start.AddDate(0, 0, int(i))},
+		})
+	}
+	first, last := objects[0].UpdatedAt.Time, objects[len(objects)-1].UpdatedAt.Time
+
+	srv := versionedServer(t, objects)
+	defer srv.Close()
+	client := NewClient("token", "store", "UTC", WithBaseURL(srv.URL), WithMaxRetries(0))
+
+	tests := []struct {
+		name   string
+		target time.Time
+	}{
+		{"before the first object", first.AddDate(0, 0, -3)},
+		{"at the first object", first},
+		{"in the middle", first.AddDate(0, 0, 20)},
+		{"between objects", first.AddDate(0, 0, 17).Add(5 * time.Hour)},
+		{"among consecutive versions", first.AddDate(0, 0, 44)},
+		{"between consecutive versions", first.AddDate(0, 0, 51).Add(5 * time.Hour)},
+		{"at the last object", last},
+		{"after the last object", last.AddDate(0, 0, 3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.VersionAt(context.Background(), "sales", tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Crawling after the version must start at the first object
+			// changed at or after the target, less the margin, so nothing
+			// is missed and nothing earlier is fetched again.
+			cutoff := tt.target.Add(-versionAtMargin)
+			var want, crawled *versionedObject
+			for i := range objects {
+				if want == nil && !objects[i].UpdatedAt.Before(cutoff) {
+					want = &objects[i]
+				}
+				if crawled == nil && objects[i].Version > got {
+					crawled = &objects[i]
+				}
+			}
+			if want != crawled {
+				t.Errorf("VersionAt = %d, crawl starts at %+v, want %+v", got, crawled, want)
+			}
+		})
+	}
+}
+
+func TestVersionAtEmpty(t *testing.T) {
+	srv := versionedServer(t, nil)
+	defer srv.Close()
+	client := NewClient("token", "store", "UTC", WithBaseURL(srv.URL), WithMaxRetries(0))
+
+	got, err := client.VersionAt(context.Background(), "sales", time.Now())
+	if err != nil || got != 0 {
+		t.Errorf("VersionAt = %d, %v, want 0", got, err)
+	}
+}